@@ -0,0 +1,67 @@
+// Package address shortens street addresses for display.
+package address
+
+import "strings"
+
+// DefaultLocale is used when no locale is given.
+const DefaultLocale = "en-US"
+
+// rules are how a locale abbreviates street names.
+type rules struct {
+	// suffixes are abbreviated when they are the last word, e.g. "Street".
+	suffixes map[string]string
+	// directions are abbreviated anywhere but the last word, so
+	// "North Point Street" shortens but "Avenue North" keeps its name.
+	directions map[string]string
+}
+
+var locales = map[string]rules{
+	"en-US": {
+		suffixes: map[string]string{
+			"Alley":     "Aly",
+			"Avenue":    "Ave",
+			"Boulevard": "Blvd",
+			"Court":     "Ct",
+			"Drive":     "Dr",
+			"Highway":   "Hwy",
+			"Lane":      "Ln",
+			"Parkway":   "Pkwy",
+			"Place":     "Pl",
+			"Road":      "Rd",
+			"Square":    "Sq",
+			"Street":    "St",
+			"Terrace":   "Ter",
+		},
+		directions: map[string]string{
+			"North": "N",
+			"South": "S",
+			"East":  "E",
+			"West":  "W",
+		},
+	},
+}
+
+// Shorten drops everything after the street part of addr (city, state,
+// postcode, country) and abbreviates street words the way the locale
+// usually does. Locales without abbreviation rules only drop the tail.
+//
+//	Shorten("1355 Market Street, San Francisco, CA 94103", "en-US") == "1355 Market St"
+func Shorten(addr, locale string) string {
+	if locale == "" {
+		locale = DefaultLocale
+	}
+	street := strings.TrimSpace(strings.SplitN(addr, ",", 2)[0])
+
+	rules := locales[locale]
+	words := strings.Fields(street)
+	for i, word := range words {
+		abbreviations := rules.directions
+		if i == len(words)-1 {
+			abbreviations = rules.suffixes
+		}
+		if short, ok := abbreviations[word]; ok {
+			words[i] = short
+		}
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,84 @@
+package bikeshare
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/themichaellai/bikealert/clock"
+)
+
+// Cache is a Provider that reuses another provider's results for a while,
+// so many callers can share one upstream request. It keeps one list of
+// vehicles and one of stations whatever the origin, so it suits providers
+// that return the whole network, as the JUMP and GBFS ones do. Vehicles
+// and stations expire separately, since vehicles move constantly and
+// stations change slowly. Concurrent requests wait for a single fetch.
+type Cache struct {
+	provider Provider
+	clock    clock.Clock
+	vehicles ttlCache[Vehicle]
+	stations ttlCache[Station]
+}
+
+// NewCache wraps provider, keeping its vehicles for vehiclesTTL and its
+// stations for stationsTTL as measured by clk. A TTL of 0 or less doesn't
+// cache.
+func NewCache(provider Provider, vehiclesTTL, stationsTTL time.Duration, clk clock.Clock) *Cache {
+	return &Cache{
+		provider: provider,
+		clock:    clk,
+		vehicles: ttlCache[Vehicle]{ttl: vehiclesTTL},
+		stations: ttlCache[Station]{ttl: stationsTTL},
+	}
+}
+
+// Unwrap returns the provider c wraps.
+func (c *Cache) Unwrap() Provider {
+	return c.provider
+}
+
+// NearbyVehicles returns the provider's vehicles, fetching them only if
+// the cached ones have expired.
+func (c *Cache) NearbyVehicles(ctx context.Context, latitude, longitude float64) ([]Vehicle, error) {
+	return c.vehicles.get(c.clock.Now, func() ([]Vehicle, error) {
+		return c.provider.NearbyVehicles(ctx, latitude, longitude)
+	})
+}
+
+// NearbyStations is like NearbyVehicles for stations.
+func (c *Cache) NearbyStations(ctx context.Context, latitude, longitude float64) ([]Station, error) {
+	return c.stations.get(c.clock.Now, func() ([]Station, error) {
+		return c.provider.NearbyStations(ctx, latitude, longitude)
+	})
+}
+
+// ttlCache keeps the last successful fetch of a list for a fixed time.
+type ttlCache[T any] struct {
+	ttl time.Duration
+
+	mu        sync.Mutex
+	fetchedAt time.Time
+	items     []T
+}
+
+// get returns the cached items if they are still fresh, and calls fetch
+// otherwise. Callers get their own copy of the slice, so sorting it
+// doesn't disturb the cache.
+func (c *ttlCache[T]) get(now func() time.Time, fetch func() ([]T, error)) ([]T, error) {
+	if c.ttl <= 0 {
+		return fetch()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// A network with nothing in it is still a result worth keeping.
+	if c.fetchedAt.IsZero() || now().Sub(c.fetchedAt) >= c.ttl {
+		items, err := fetch()
+		if err != nil {
+			return nil, err
+		}
+		c.items, c.fetchedAt = items, now()
+	}
+	return append([]T(nil), c.items...), nil
+}
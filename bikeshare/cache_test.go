@@ -0,0 +1,102 @@
+package bikeshare
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/themichaellai/bikealert/clock"
+)
+
+// countingProvider returns fixed results and counts its calls.
+type countingProvider struct {
+	vehicles      []Vehicle
+	stations      []Station
+	err           error
+	vehicleCalls  int
+	stationsCalls int
+}
+
+func (p *countingProvider) NearbyVehicles(ctx context.Context, latitude, longitude float64) ([]Vehicle, error) {
+	p.vehicleCalls++
+	return p.vehicles, p.err
+}
+
+func (p *countingProvider) NearbyStations(ctx context.Context, latitude, longitude float64) ([]Station, error) {
+	p.stationsCalls++
+	return p.stations, p.err
+}
+
+func TestCacheTTLs(t *testing.T) {
+	tests := []struct {
+		name                     string
+		vehiclesTTL, stationsTTL time.Duration
+		// Each call is made after advancing the clock by step.
+		step                           time.Duration
+		wantVehicleCalls, wantStations int
+	}{
+		{name: "no caching", step: time.Second, wantVehicleCalls: 4, wantStations: 4},
+		{name: "within both TTLs", vehiclesTTL: time.Minute, stationsTTL: time.Hour, step: time.Second, wantVehicleCalls: 1, wantStations: 1},
+		{name: "stations outlive vehicles", vehiclesTTL: 30 * time.Second, stationsTTL: time.Hour, step: 20 * time.Second, wantVehicleCalls: 2, wantStations: 1},
+		{name: "vehicles only", vehiclesTTL: time.Minute, step: time.Second, wantVehicleCalls: 1, wantStations: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clk := clock.NewFake(time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC))
+			provider := &countingProvider{vehicles: []Vehicle{{ID: "a"}}, stations: []Station{{ID: "b"}}}
+			c := NewCache(provider, tt.vehiclesTTL, tt.stationsTTL, clk)
+			for i := 0; i < 4; i++ {
+				if _, err := c.NearbyVehicles(context.Background(), 0, 0); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := c.NearbyStations(context.Background(), 0, 0); err != nil {
+					t.Fatal(err)
+				}
+				clk.Advance(tt.step)
+			}
+			if provider.vehicleCalls != tt.wantVehicleCalls || provider.stationsCalls != tt.wantStations {
+				t.Errorf("fetched vehicles %d times and stations %d times, want %d and %d",
+					provider.vehicleCalls, provider.stationsCalls, tt.wantVehicleCalls, tt.wantStations)
+			}
+		})
+	}
+}
+
+func TestCacheKeepsEmptyResults(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC))
+	provider := &countingProvider{}
+	c := NewCache(provider, time.Minute, time.Minute, clk)
+	for i := 0; i < 3; i++ {
+		c.NearbyVehicles(context.Background(), 0, 0)
+	}
+	if provider.vehicleCalls != 1 {
+		t.Errorf("fetched an empty network %d times, want 1", provider.vehicleCalls)
+	}
+}
+
+func TestCacheDoesNotKeepErrors(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC))
+	provider := &countingProvider{err: errors.New("down")}
+	c := NewCache(provider, time.Minute, time.Minute, clk)
+	for i := 0; i < 2; i++ {
+		if _, err := c.NearbyVehicles(context.Background(), 0, 0); err == nil {
+			t.Error("NearbyVehicles() error = nil, want the provider's error")
+		}
+	}
+	if provider.vehicleCalls != 2 {
+		t.Errorf("fetched %d times after errors, want 2", provider.vehicleCalls)
+	}
+}
+
+func TestCacheReturnsCopies(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC))
+	provider := &countingProvider{vehicles: []Vehicle{{ID: "a"}, {ID: "b"}}}
+	c := NewCache(provider, time.Minute, time.Minute, clk)
+	first, _ := c.NearbyVehicles(context.Background(), 0, 0)
+	first[0], first[1] = first[1], first[0]
+	second, _ := c.NearbyVehicles(context.Background(), 0, 0)
+	if second[0].ID != "a" {
+		t.Errorf("sorting a result changed the cache: got %v", second)
+	}
+}
@@ -0,0 +1,67 @@
+package bikeshare
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Provider is a bikeshare system that vehicles and stations can be
+// fetched from.
+type Provider interface {
+	// NearbyVehicles returns vehicles around the given coordinates.
+	// Providers that can't query by location return every vehicle, so
+	// callers should still filter by distance.
+	NearbyVehicles(ctx context.Context, latitude, longitude float64) ([]Vehicle, error)
+	// NearbyStations is like NearbyVehicles for stations.
+	NearbyStations(ctx context.Context, latitude, longitude float64) ([]Station, error)
+}
+
+// Factory creates a provider from its settings, such as an API key or
+// feed URL. Which settings are understood is up to the provider, and
+// unknown ones are ignored.
+type Factory func(settings map[string]string) (Provider, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = map[string]Factory{}
+)
+
+// Register makes a provider available by name to Open. Providers call it
+// from an init function. It panics if the name is already registered.
+func Register(name string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	if _, dup := factories[name]; dup {
+		panic(fmt.Sprintf("bikeshare: Register called twice for provider \"%s\"", name))
+	}
+	factories[name] = factory
+}
+
+// Open creates a provider registered under name.
+func Open(name string, settings map[string]string) (Provider, error) {
+	factoriesMu.RLock()
+	factory, ok := factories[name]
+	factoriesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("bikeshare.Open: unknown provider \"%s\" (registered: %v)", name, Providers())
+	}
+	p, err := factory(settings)
+	if err != nil {
+		return nil, fmt.Errorf("bikeshare.Open: %s: %w", name, err)
+	}
+	return p, nil
+}
+
+// Providers returns the names of the registered providers, sorted.
+func Providers() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
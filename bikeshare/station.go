@@ -0,0 +1,23 @@
+package bikeshare
+
+// Station is a hub or dock where vehicles are parked, from any provider.
+type Station struct {
+	// Provider is the name of the provider the station came from.
+	Provider string
+	ID       string
+	Name     string
+
+	Latitude  float64
+	Longitude float64
+	Address   string
+
+	// AvailableVehicles is how many vehicles can be rented right now.
+	AvailableVehicles int
+	// AvailableDocks is how many vehicles can be returned right now, or
+	// -1 if the provider doesn't report it.
+	AvailableDocks int
+
+	// Extension holds the provider's own representation of the station.
+	// Providers export a typed accessor for it, e.g. jump.HubFromStation.
+	Extension interface{}
+}
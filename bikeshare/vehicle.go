@@ -0,0 +1,47 @@
+// Package bikeshare has provider-independent types for shared bikes and
+// scooters.
+package bikeshare
+
+// VehicleType is the kind of a vehicle.
+type VehicleType string
+
+// Known vehicle types.
+const (
+	VehicleBike    VehicleType = "bike"
+	VehicleEbike   VehicleType = "ebike"
+	VehicleScooter VehicleType = "scooter"
+)
+
+// Vehicle is a bike or scooter from any provider.
+type Vehicle struct {
+	// Provider is the name of the provider the vehicle came from.
+	Provider string
+	ID       string
+	Name     string
+	Type     VehicleType
+
+	Latitude  float64
+	Longitude float64
+	Address   string
+
+	// BatteryLevel is a percentage, or -1 if the vehicle has no battery
+	// or the provider doesn't report it.
+	BatteryLevel int
+	// RangeMiles is the estimated range on the current charge, or 0 if
+	// unknown.
+	RangeMiles float64
+
+	// Pricing is nil if the provider doesn't say what a trip costs.
+	Pricing *Pricing
+
+	// Extension holds the provider's own representation of the vehicle.
+	// Providers export a typed accessor for it, e.g. jump.BikeFromVehicle.
+	Extension interface{}
+}
+
+// Pricing is a hint at what renting a vehicle costs.
+type Pricing struct {
+	Currency  string
+	UnlockFee float64
+	PerMinute float64
+}
@@ -0,0 +1,67 @@
+// Package clock abstracts the passage of time, so code that depends on it
+// can be tested or run in simulated time.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the time and waits.
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+	// After returns a channel that receives the time once d has passed,
+	// so a wait can be abandoned, e.g. when a context is cancelled.
+	After(d time.Duration) <-chan time.Time
+}
+
+// Real is the system clock.
+var Real Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// Fake is a Clock that only moves when told to. Sleep and After return
+// immediately after advancing the clock, so simulations run as fast as
+// possible.
+type Fake struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFake returns a Fake set to the given time.
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+// Now returns the fake time.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Sleep advances the fake time by d.
+func (f *Fake) Sleep(d time.Duration) {
+	f.Advance(d)
+}
+
+// After advances the fake time by d and returns a channel that already
+// holds the new time.
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	f.Advance(d)
+	ch := make(chan time.Time, 1)
+	ch <- f.Now()
+	return ch
+}
+
+// Advance moves the fake time forward by d.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
@@ -0,0 +1,28 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFake(t *testing.T) {
+	start := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	f := NewFake(start)
+
+	f.Sleep(time.Minute)
+	if got, want := f.Now(), start.Add(time.Minute); !got.Equal(want) {
+		t.Errorf("after Sleep, Now() = %s, want %s", got, want)
+	}
+
+	select {
+	case got := <-f.After(time.Hour):
+		if want := start.Add(time.Hour + time.Minute); !got.Equal(want) {
+			t.Errorf("After() sent %s, want %s", got, want)
+		}
+	default:
+		t.Error("After() channel isn't ready")
+	}
+	if got, want := f.Now(), start.Add(time.Hour+time.Minute); !got.Equal(want) {
+		t.Errorf("after After, Now() = %s, want %s", got, want)
+	}
+}
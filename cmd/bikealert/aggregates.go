@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/themichaellai/bikealert/geo"
+)
+
+// aggregatesSchema identifies the format written by export-aggregates.
+// Fields may be added within a version; anything else bumps it.
+const aggregatesSchema = "bikealert.aggregates/v1"
+
+// aggregates is the export-aggregates output. It has counts only: no
+// vehicle IDs, exact positions, or anything about where the user is.
+type aggregates struct {
+	Schema      string    `json:"schema"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Provider    string    `json:"provider"`
+
+	// Cells counts free-floating vehicles in roughly 275 meter squares,
+	// identified by 8-digit plus codes such as "849VQHFJ+".
+	Cells []aggregateCell `json:"cells"`
+	// Stations is the availability at each station.
+	Stations []aggregateStation `json:"stations"`
+}
+
+type aggregateCell struct {
+	Cell     string `json:"cell"`
+	Vehicles int    `json:"vehicles"`
+	// AverageBattery is over vehicles that report it, or null if none do.
+	AverageBattery *float64 `json:"average_battery"`
+}
+
+type aggregateStation struct {
+	ID                string `json:"id"`
+	Name              string `json:"name"`
+	Cell              string `json:"cell"`
+	AvailableVehicles int    `json:"available_vehicles"`
+	// AvailableDocks is -1 if the provider doesn't report it.
+	AvailableDocks int `json:"available_docks"`
+}
+
+var (
+	exportAggregatesFlags = newLocationFlagSet("export-aggregates")
+	exportAggregatesOut   = exportAggregatesFlags.String("out", "", "file to write, or - for stdout")
+)
+
+// runExportAggregates writes anonymized availability counts, for sharing
+// with neighborhood groups that pool data from several instances.
+// $LAT/$LNG only choose where to ask the provider and aren't written out.
+func runExportAggregates(args []string) error {
+	if *exportAggregatesOut == "" {
+		return usageErrorf("usage: bikealert export-aggregates --out file.json")
+	}
+
+	latitude, longitude, err := getOrigin()
+	if err != nil {
+		return err
+	}
+	provider, err := newProvider()
+	if err != nil {
+		return err
+	}
+	vehicles, stations, err := fetchAll(provider, latitude, longitude)
+	if err != nil {
+		return err
+	}
+
+	type cellTotals struct {
+		vehicles, withBattery, batterySum int
+	}
+	totals := map[string]*cellTotals{}
+	for _, vehicle := range vehicles {
+		cell := plusCodeCell(vehicle.Latitude, vehicle.Longitude)
+		t := totals[cell]
+		if t == nil {
+			t = &cellTotals{}
+			totals[cell] = t
+		}
+		t.vehicles++
+		if vehicle.BatteryLevel >= 0 {
+			t.withBattery++
+			t.batterySum += vehicle.BatteryLevel
+		}
+	}
+
+	result := aggregates{
+		Schema:      aggregatesSchema,
+		GeneratedAt: clk.Now().UTC(),
+		Provider:    providerName(),
+		Cells:       []aggregateCell{},
+		Stations:    []aggregateStation{},
+	}
+	for cell, t := range totals {
+		c := aggregateCell{Cell: cell, Vehicles: t.vehicles}
+		if t.withBattery > 0 {
+			average := float64(t.batterySum) / float64(t.withBattery)
+			c.AverageBattery = &average
+		}
+		result.Cells = append(result.Cells, c)
+	}
+	sort.Slice(result.Cells, func(i, j int) bool {
+		return result.Cells[i].Cell < result.Cells[j].Cell
+	})
+	for _, station := range stations {
+		result.Stations = append(result.Stations, aggregateStation{
+			ID:                station.ID,
+			Name:              station.Name,
+			Cell:              plusCodeCell(station.Latitude, station.Longitude),
+			AvailableVehicles: station.AvailableVehicles,
+			AvailableDocks:    station.AvailableDocks,
+		})
+	}
+	sort.Slice(result.Stations, func(i, j int) bool {
+		return result.Stations[i].ID < result.Stations[j].ID
+	})
+
+	if *exportAggregatesOut == "-" {
+		return json.NewEncoder(os.Stdout).Encode(result)
+	}
+	return writeJSONFile(*exportAggregatesOut, result)
+}
+
+// plusCodeCell returns the 8-digit plus code containing a coordinate,
+// e.g. "849VQHFJ+".
+func plusCodeCell(lat, lng float64) string {
+	return geo.PlusCode(lat, lng)[:8] + "+"
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/themichaellai/bikealert/bikeshare"
+	"github.com/themichaellai/bikealert/internal/units"
+	"github.com/themichaellai/bikealert/notify"
+)
+
+var (
+	alertFlags       = newLocationFlagSet("alert")
+	alertInteractive = alertFlags.Bool("interactive", false, "ask for each setting, previewing it against current bikes")
+	alertMaxDistance = alertFlags.String("max-distance", "", "furthest a bike may be, e.g. 0.3mi or 500m")
+	alertMinBattery  = alertFlags.String("min-battery", "", "lowest battery a bike may have, e.g. 50%")
+	alertGroupSize   = alertFlags.Int("group-size", 0, "how many bikes are needed")
+)
+
+// runAlert handles "alert new", which writes the alert limits countdown
+// and the daemon use into the config file, and optionally a notifier.
+// Limits not given keep their current values.
+func runAlert(args []string) error {
+	if len(args) == 0 || args[0] != "new" {
+		return usageErrorf("usage: bikealert alert new [--interactive]")
+	}
+	// Flags may also follow "new".
+	if err := parseCommandFlags(alertFlags, args[1:]); err != nil {
+		return err
+	}
+
+	alerts := cfg.alerts
+	if *alertMaxDistance != "" {
+		alerts.MaxDistance = *alertMaxDistance
+	}
+	if *alertMinBattery != "" {
+		alerts.MinBattery = *alertMinBattery
+	}
+	if *alertGroupSize != 0 {
+		alerts.GroupSize = *alertGroupSize
+	}
+	var notifier *notify.Config
+	if *alertInteractive {
+		w := &alertWizard{in: bufio.NewReader(os.Stdin), out: os.Stdout, alerts: alerts}
+		if err := w.run(); err != nil {
+			return err
+		}
+		if !w.confirmed {
+			fmt.Println("Nothing written.")
+			return nil
+		}
+		alerts, notifier = w.alerts, w.notifier
+	} else if *alertMaxDistance == "" && *alertMinBattery == "" && *alertGroupSize == 0 {
+		return usageErrorf("usage: bikealert alert new --interactive, or give --max-distance, --min-battery or --group-size")
+	}
+
+	if _, err := (configFile{Alerts: alerts}).parse(); err != nil {
+		return err
+	}
+	fields := map[string]interface{}{"alerts": alerts}
+	if notifier != nil {
+		fields["notify"] = append(cfg.notify, *notifier)
+	}
+	path, err := updateConfigFile(fields)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Saved to %s\n", path)
+	return nil
+}
+
+// alertWizard asks for alert settings one at a time. After each, it shows
+// how many of the bikes around the origin right now would pass.
+type alertWizard struct {
+	in  *bufio.Reader
+	out io.Writer
+
+	alerts   configAlerts
+	notifier *notify.Config
+	// vehicles are the current bikes to preview against, or nil if they
+	// couldn't be fetched.
+	vehicles            []bikeshare.Vehicle
+	latitude, longitude float64
+	confirmed           bool
+}
+
+func (w *alertWizard) run() error {
+	w.loadPreview()
+
+	def := w.alerts.MaxDistance
+	if def == "" {
+		def = "none"
+	}
+	for {
+		answer, err := w.ask("Furthest a bike may be, e.g. 0.3mi or 500m, or none", def)
+		if err != nil {
+			return err
+		}
+		if answer == "none" {
+			w.alerts.MaxDistance = ""
+			break
+		}
+		if _, err := units.ParseDistance(answer); err != nil {
+			fmt.Fprintf(w.out, "  %s\n", err.Error())
+			continue
+		}
+		w.alerts.MaxDistance = answer
+		break
+	}
+	w.preview()
+
+	def = w.alerts.MinBattery
+	if def == "" {
+		def = "none"
+	}
+	for {
+		answer, err := w.ask("Lowest battery a bike may have, e.g. 50%, or none", def)
+		if err != nil {
+			return err
+		}
+		if answer == "none" {
+			w.alerts.MinBattery = ""
+			break
+		}
+		if _, err := units.ParsePercent(answer); err != nil {
+			fmt.Fprintf(w.out, "  %s\n", err.Error())
+			continue
+		}
+		w.alerts.MinBattery = answer
+		break
+	}
+	w.preview()
+
+	def = "1"
+	if w.alerts.GroupSize > 0 {
+		def = strconv.Itoa(w.alerts.GroupSize)
+	}
+	for {
+		answer, err := w.ask("How many bikes are needed", def)
+		if err != nil {
+			return err
+		}
+		n, err := strconv.Atoi(answer)
+		if err != nil || n < 1 {
+			fmt.Fprintf(w.out, "  enter a whole number of at least 1\n")
+			continue
+		}
+		w.alerts.GroupSize = n
+		if n == 1 {
+			w.alerts.GroupSize = 0
+		}
+		break
+	}
+	w.preview()
+
+	if err := w.askNotifier(); err != nil {
+		return err
+	}
+
+	var conditions []string
+	if w.alerts.MaxDistance != "" {
+		conditions = append(conditions, "within "+w.alerts.MaxDistance)
+	}
+	if w.alerts.MinBattery != "" {
+		conditions = append(conditions, "at "+w.alerts.MinBattery+" battery or more")
+	}
+	if len(conditions) == 0 {
+		conditions = append(conditions, "around")
+	}
+	fmt.Fprintf(w.out, "\nCountdown and the daemon will alert when fewer than %d bikes are %s.\n",
+		max(w.alerts.GroupSize, 1), strings.Join(conditions, " and "))
+	if w.notifier != nil {
+		fmt.Fprintf(w.out, "Alerts will also go to %s.\n", w.notifier.Type)
+	}
+	answer, err := w.ask("Save this to the config file? (y/n)", "y")
+	if err != nil {
+		return err
+	}
+	w.confirmed = strings.HasPrefix(strings.ToLower(answer), "y")
+	return nil
+}
+
+// askNotifier asks where else to send alerts, and the settings that
+// destination needs.
+func (w *alertWizard) askNotifier() error {
+	for {
+		answer, err := w.ask("Also send alerts by slack, pushover, webhook, email, or none", "none")
+		if err != nil {
+			return err
+		}
+		n := notify.Config{Type: answer}
+		switch answer {
+		case "none":
+			return nil
+		case "slack", "webhook":
+			err = w.askFields(wizardField{"Webhook URL", &n.URL})
+		case "pushover":
+			err = w.askFields(
+				wizardField{"Pushover app token", &n.Token},
+				wizardField{"Pushover user key", &n.User},
+			)
+		case "email":
+			var to string
+			err = w.askFields(
+				wizardField{"SMTP server, e.g. smtp.example.com:587", &n.Addr},
+				wizardField{"SMTP username, if any", &n.Username},
+				wizardField{"SMTP password, if any", &n.Password},
+				wizardField{"From address", &n.From},
+				wizardField{"To address", &to},
+			)
+			if to != "" {
+				n.To = []string{to}
+			}
+		default:
+			fmt.Fprintf(w.out, "  unknown notifier \"%s\"\n", answer)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := notify.New(n); err != nil {
+			fmt.Fprintf(w.out, "  %s\n", err.Error())
+			continue
+		}
+		w.notifier = &n
+		return nil
+	}
+}
+
+// wizardField is a question whose answer is stored in value.
+type wizardField struct {
+	question string
+	value    *string
+}
+
+// askFields asks each question in turn, with no default.
+func (w *alertWizard) askFields(fields ...wizardField) error {
+	for _, f := range fields {
+		answer, err := w.ask(f.question, "")
+		if err != nil {
+			return err
+		}
+		*f.value = answer
+	}
+	return nil
+}
+
+// ask prints a question and returns the trimmed answer, or def if the
+// answer is blank.
+func (w *alertWizard) ask(question, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(w.out, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(w.out, "%s: ", question)
+	}
+	line, err := w.in.ReadString('\n')
+	if errors.Is(err, io.EOF) && line == "" {
+		return "", fmt.Errorf("input closed before the alert was finished")
+	} else if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	if answer := strings.TrimSpace(line); answer != "" {
+		return answer, nil
+	}
+	return def, nil
+}
+
+// loadPreview fetches the bikes around the origin to preview against.
+// Without an origin or a provider the wizard still works, just without
+// previews.
+func (w *alertWizard) loadPreview() {
+	var err error
+	w.latitude, w.longitude, err = getOrigin()
+	if err != nil {
+		fmt.Fprintf(w.out, "No preview: %s\n\n", err.Error())
+		return
+	}
+	ignoredBikes, err := loadIgnoredBikes()
+	if err != nil {
+		fmt.Fprintf(w.out, "No preview: %s\n\n", err.Error())
+		return
+	}
+	provider, err := newProvider()
+	if err == nil {
+		w.vehicles, err = fetchVehicles(provider, w.latitude, w.longitude)
+	}
+	if err != nil {
+		fmt.Fprintf(w.out, "No preview: %s\n\n", err.Error())
+		return
+	}
+	w.vehicles = removeIgnored(w.vehicles, ignoredBikes)
+	fmt.Fprintf(w.out, "Previewing against the %d bikes around %f, %f right now.\n\n",
+		len(w.vehicles), w.latitude, w.longitude)
+}
+
+// preview shows how many current bikes pass the settings so far, and
+// whether that would set off an alert.
+func (w *alertWizard) preview() {
+	if w.vehicles == nil {
+		return
+	}
+	c, err := (configFile{Alerts: w.alerts}).parse()
+	if err != nil {
+		return
+	}
+	groupSize := max(c.limits.groupSize, 1)
+	n := c.limits.countAcceptable(w.vehicles, w.latitude, w.longitude)
+	verdict := "no alert right now"
+	if n < groupSize {
+		verdict = "this would alert right now"
+	}
+	fmt.Fprintf(w.out, "  %d of %d bikes pass, %d needed: %s\n", n, len(w.vehicles), groupSize, verdict)
+	if unknown := c.limits.countUnknownBattery(w.vehicles, w.latitude, w.longitude); unknown > 0 {
+		fmt.Fprintf(w.out, "  %d bikes nearby have an unknown battery level, so never pass a battery minimum\n", unknown)
+	}
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/themichaellai/bikealert/jump"
+)
+
+var (
+	benchFlags      = flag.NewFlagSet("bench", flag.ContinueOnError)
+	benchBikes      = benchFlags.Int("bikes", 20000, "number of synthetic bikes in the network")
+	benchIterations = benchFlags.Int("iterations", 20, "number of polls to time")
+)
+
+// runBench times the fetch, decode, filter and rank path against a local
+// server serving a synthetic network, so performance changes show up
+// without hitting upstream.
+func runBench(args []string) error {
+	if *benchIterations < 1 {
+		return fmt.Errorf("--iterations must be at least 1")
+	}
+
+	// Synthetic bikes scattered over roughly a 10 mile square around SF.
+	const latitude, longitude = 37.7749, -122.4194
+	bikes := make([]jump.Bike, *benchBikes)
+	for i := range bikes {
+		bikes[i] = jump.Bike{
+			ID:                int64(i),
+			Name:              fmt.Sprintf("%06d", i),
+			EbikeBatteryLevel: int64(rng.Intn(101)),
+			Address:           fmt.Sprintf("%d Market Street, San Francisco, CA 94103", i),
+			CurrentPosition: jump.Position{Coordinates: []float64{
+				longitude + (rng.Float64()-0.5)*0.18,
+				latitude + (rng.Float64()-0.5)*0.14,
+			}},
+		}
+	}
+	payload, err := json.Marshal(map[string]interface{}{"items": bikes})
+	if err != nil {
+		return err
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(payload)
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		return err
+	}
+	toServer := func(next http.RoundTripper) http.RoundTripper {
+		return jump.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			req.URL.Scheme, req.URL.Host = serverURL.Scheme, serverURL.Host
+			return next.RoundTrip(req)
+		})
+	}
+	provider := jump.NewProvider(jump.NewClient(jump.NetworkSanFrancisco, jump.WithMiddleware(toServer)))
+	ignored := map[string]bool{"000001": true}
+
+	var durations []time.Duration
+	for i := 0; i < *benchIterations; i++ {
+		start := time.Now()
+		vehicles, err := fetchVehicles(provider, latitude, longitude)
+		if err != nil {
+			return err
+		}
+		vehicles = removeIgnored(vehicles, ignored)
+		sortVehicles(vehicles, latitude, longitude)
+		durations = append(durations, time.Since(start))
+	}
+
+	sort.Slice(durations, func(i, j int) bool {
+		return durations[i] < durations[j]
+	})
+	fmt.Printf("%d bikes (%d KiB), %d polls: min %s, median %s, max %s\n",
+		*benchBikes, len(payload)/1024, *benchIterations,
+		durations[0], durations[len(durations)/2], durations[len(durations)-1])
+	return nil
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// command is a bikealert subcommand.
+type command struct {
+	name    string
+	aliases []string
+	// args describes the positional arguments, e.g. "<bike name>".
+	args    string
+	summary string
+	example string
+	// flags are the flags that may follow the command name.
+	flags *flag.FlagSet
+	// run is called with the arguments left after flags.
+	run func(args []string) error
+}
+
+// commands lists every subcommand, in the order help shows them. It is
+// filled in by init, since help and completion refer back to it.
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{
+			name:    "nearest",
+			summary: "print the closest bike on one line",
+			example: "bikealert nearest --location home",
+			flags:   newLocationFlagSet("nearest"),
+			run:     runNearest,
+		},
+		{
+			name:    "bikes",
+			summary: "list the closest bikes",
+			example: "bikealert bikes --lat 37.776 --lng -122.418 --limit 10",
+			flags:   bikesFlags,
+			run:     runBikes,
+		},
+		{
+			name:    "hubs",
+			summary: "list the closest hubs",
+			example: "bikealert hubs --address '1355 Market St, San Francisco'",
+			flags:   hubsFlags,
+			run:     runHubs,
+		},
+		{
+			name:    "countdown",
+			summary: "show the best bike until $DEPART, alerting if it gets worse",
+			example: "DEPART=08:45 MIN_BATTERY=50% bikealert countdown",
+			flags:   newLocationFlagSet("countdown"),
+			run:     runCountdown,
+		},
+		{
+			name:    "daemon",
+			aliases: []string{"watch"},
+			summary: "poll until stopped, notifying when bikes run low",
+			example: "MAX_DISTANCE=0.3mi bikealert watch --interval 2m",
+			flags:   daemonFlags,
+			run:     runDaemon,
+		},
+		{
+			name:    "server",
+			summary: "serve the closest bikes and hubs as a JSON API",
+			example: "bikealert server --addr :8080",
+			flags:   serverFlags,
+			run:     runServer,
+		},
+		{
+			name:    "stats",
+			summary: "show hub availability by hour from the stored history",
+			example: "bikealert stats --hub 'Market St' --at 08:45",
+			flags:   statsFlags,
+			run:     runStats,
+		},
+		{
+			name:    "alert",
+			args:    "new",
+			summary: "set the alert limits and notifier in the config file",
+			example: "bikealert alert new --interactive",
+			flags:   alertFlags,
+			run:     runAlert,
+		},
+		{
+			name:    "notify",
+			args:    "test",
+			summary: "send a sample alert built from current bikes to each notifier",
+			example: "bikealert notify test --channel slack",
+			flags:   notifyFlags,
+			run:     runNotify,
+		},
+		{
+			name:    "report-broken",
+			args:    "<bike name>",
+			summary: "ignore a bike from now on and draft a maintenance report",
+			example: "bikealert report-broken 1355",
+			flags:   newLocationFlagSet("report-broken"),
+			run:     runReportBroken,
+		},
+		{
+			name:    "origins",
+			args:    "name=lat,lng [name=lat,lng ...]",
+			summary: "find the starting point with the shortest walk to a bike",
+			example: "bikealert origins north=37.7770,-122.4180 south=37.7755,-122.4185",
+			flags:   newProviderFlagSet("origins"),
+			run:     runOrigins,
+		},
+		{
+			name:    "meet",
+			args:    "name=lat,lng name=lat,lng",
+			summary: "find bikes and hubs that are fair for two people to meet at",
+			example: "bikealert meet ana=37.776,-122.418 ben=37.789,-122.401",
+			flags:   newProviderFlagSet("meet"),
+			run:     runMeet,
+		},
+		{
+			name:    "bench",
+			summary: "time fetching and ranking against a synthetic network",
+			example: "bikealert -cpuprofile cpu.out bench --bikes 20000",
+			flags:   benchFlags,
+			run:     runBench,
+		},
+		{
+			name:    "export-site",
+			summary: "write a static page and GeoJSON of nearby availability",
+			example: "bikealert export-site --out public/",
+			flags:   exportSiteFlags,
+			run:     runExportSite,
+		},
+		{
+			name:    "export-aggregates",
+			summary: "write anonymized availability counts for sharing",
+			example: "bikealert export-aggregates --out - | curl --data-binary @- https://example.org/upload",
+			flags:   exportAggregatesFlags,
+			run:     runExportAggregates,
+		},
+		{
+			name:    "completion",
+			args:    "bash|zsh|fish",
+			summary: "print a shell completion script",
+			example: "source <(bikealert completion bash)",
+			flags:   flag.NewFlagSet("completion", flag.ContinueOnError),
+			run:     runCompletion,
+		},
+		{
+			name:    "help",
+			args:    "[command]",
+			summary: "show help for bikealert or a command",
+			example: "bikealert help daemon",
+			flags:   flag.NewFlagSet("help", flag.ContinueOnError),
+			run:     runHelp,
+		},
+	}
+	for _, cmd := range commands {
+		cmd := cmd
+		cmd.flags.Usage = func() { printCommandHelp(cmd) }
+	}
+	flag.Usage = printHelp
+}
+
+// usageError is a mistake in how bikealert was called, such as an unknown
+// command. It exits with status 2, like a bad flag.
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+// usageErrorf returns a usageError with a formatted message.
+func usageErrorf(format string, args ...interface{}) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// parseCommandFlags parses a command's flags. The flag package has already
+// printed any bad flag along with the command's help, so that comes back
+// as a usageError with no message of its own.
+func parseCommandFlags(flags *flag.FlagSet, args []string) error {
+	err := flags.Parse(args)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		return &usageError{}
+	}
+	return err
+}
+
+// newLocationFlagSet returns a flag set for a command that searches around
+// an origin, with addLocationFlags already added.
+func newLocationFlagSet(name string) *flag.FlagSet {
+	flags := newProviderFlagSet(name)
+	addLocationFlags(flags)
+	return flags
+}
+
+// newProviderFlagSet returns a flag set for a command that fetches from a
+// provider, with addProviderFlags already added.
+func newProviderFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	addProviderFlags(flags)
+	return flags
+}
+
+// findCommand returns the command called name, or nil.
+func findCommand(name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
+		}
+		for _, alias := range cmd.aliases {
+			if alias == name {
+				return cmd
+			}
+		}
+	}
+	return nil
+}
+
+// runCommand parses args, starting with the command's name, and runs it.
+func runCommand(args []string) error {
+	if args[0] == completeCommand {
+		return runComplete(args[1:])
+	}
+	cmd := findCommand(args[0])
+	if cmd == nil {
+		return usageErrorf("unknown command \"%s\"; see bikealert help", args[0])
+	}
+	if err := parseCommandFlags(cmd.flags, args[1:]); err != nil {
+		return err
+	}
+	return cmd.run(cmd.flags.Args())
+}
+
+// runHelp prints help for bikealert, or for the named command. Unlike
+// help after a usage error, it goes to stdout.
+func runHelp(args []string) error {
+	flag.CommandLine.SetOutput(os.Stdout)
+	if len(args) == 0 {
+		printHelp()
+		return nil
+	}
+	cmd := findCommand(args[0])
+	if cmd == nil {
+		return usageErrorf("unknown command \"%s\"", args[0])
+	}
+	cmd.flags.SetOutput(os.Stdout)
+	printCommandHelp(cmd)
+	return nil
+}
+
+// printHelp lists the commands and global flags.
+func printHelp() {
+	w := flag.CommandLine.Output()
+	fmt.Fprintf(w, "Usage: bikealert [flags] [command] [command flags]\n\n")
+	fmt.Fprintf(w, "With no command, lists the closest bikes and hubs.\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-18s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(w, "\nExamples:\n")
+	fmt.Fprintf(w, "  LAT=37.776 LNG=-122.418 bikealert\n")
+	fmt.Fprintf(w, "  bikealert --output json bikes --location work\n")
+	fmt.Fprintf(w, "\nFlags:\n")
+	flag.PrintDefaults()
+	fmt.Fprintf(w, "\nRun bikealert help <command> for a command's flags.\n")
+}
+
+// printCommandHelp describes one command and its flags.
+func printCommandHelp(cmd *command) {
+	w := cmd.flags.Output()
+	usage := "bikealert [flags] " + cmd.name + " [command flags]"
+	if cmd.args != "" {
+		usage += " " + cmd.args
+	}
+	fmt.Fprintf(w, "Usage: %s\n\n", usage)
+	fmt.Fprintf(w, "%s%s.\n", strings.ToUpper(cmd.summary[:1]), cmd.summary[1:])
+	if len(cmd.aliases) > 0 {
+		fmt.Fprintf(w, "Also called: %s\n", strings.Join(cmd.aliases, ", "))
+	}
+	fmt.Fprintf(w, "\nExample:\n  %s\n", cmd.example)
+	hasFlags := false
+	cmd.flags.VisitAll(func(*flag.Flag) { hasFlags = true })
+	if hasFlags {
+		fmt.Fprintf(w, "\nFlags:\n")
+		cmd.flags.PrintDefaults()
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/themichaellai/bikealert/bikeshare"
+)
+
+// completeCommand is the hidden command completion scripts call to list
+// candidates, so the scripts themselves stay small and never go stale.
+const completeCommand = "__complete"
+
+var completionScripts = map[string]string{
+	"bash": `# bash completion for bikealert
+_bikealert() {
+	local cur=${COMP_WORDS[COMP_CWORD]} IFS=$'\n'
+	COMPREPLY=($(compgen -W "$(bikealert __complete "${COMP_WORDS[@]:1:COMP_CWORD-1}" 2>/dev/null)" -- "$cur"))
+}
+complete -o default -F _bikealert bikealert
+`,
+	"zsh": `#compdef bikealert
+# zsh completion for bikealert
+_bikealert() {
+	local -a candidates
+	candidates=(${(f)"$(bikealert __complete ${words[2,CURRENT-1]} 2>/dev/null)"})
+	if (( ${#candidates} )); then
+		compadd -a candidates
+	else
+		_files
+	fi
+}
+if [ "$funcstack[1]" = "_bikealert" ]; then
+	_bikealert "$@"
+else
+	compdef _bikealert bikealert
+fi
+`,
+	"fish": `# fish completion for bikealert
+function __bikealert_complete
+	bikealert __complete (commandline -opc)[2..-1] 2>/dev/null
+end
+complete -c bikealert -a '(__bikealert_complete)'
+`,
+}
+
+// runCompletion prints the completion script for a shell.
+func runCompletion(args []string) error {
+	if len(args) != 1 {
+		return usageErrorf("usage: bikealert completion bash|zsh|fish")
+	}
+	script, ok := completionScripts[args[0]]
+	if !ok {
+		return usageErrorf("no completion for shell \"%s\"; try bash, zsh or fish", args[0])
+	}
+	fmt.Print(script)
+	return nil
+}
+
+// runComplete prints the candidates for the word after words, one per
+// line. The shell filters them by what has been typed so far.
+func runComplete(words []string) error {
+	for _, candidate := range completions(words) {
+		fmt.Println(candidate)
+	}
+	return nil
+}
+
+// completions returns the candidates for the word after words: a flag's
+// values if the last word is a flag that takes one, and otherwise the
+// flags and arguments valid at that point.
+func completions(words []string) []string {
+	flags := flag.CommandLine
+	var cmd *command
+	var valueFlag *flag.Flag
+	for _, word := range words {
+		if valueFlag != nil {
+			// bash splits --flag=value into three words.
+			if word != "=" {
+				valueFlag = nil
+			}
+			continue
+		}
+		if strings.HasPrefix(word, "-") {
+			name := strings.TrimLeft(word, "-")
+			if strings.Contains(name, "=") {
+				continue
+			}
+			if f := flags.Lookup(name); f != nil && !isBoolFlag(f) {
+				valueFlag = f
+			}
+			continue
+		}
+		if cmd == nil {
+			if cmd = findCommand(word); cmd == nil {
+				return nil
+			}
+			flags = cmd.flags
+		}
+	}
+
+	if valueFlag != nil {
+		return flagValues(valueFlag.Name)
+	}
+	var candidates []string
+	if cmd == nil {
+		for _, c := range commands {
+			candidates = append(candidates, c.name)
+			candidates = append(candidates, c.aliases...)
+		}
+	} else {
+		candidates = append(candidates, argValues(cmd.name)...)
+	}
+	flags.VisitAll(func(f *flag.Flag) {
+		candidates = append(candidates, "--"+f.Name)
+	})
+	return candidates
+}
+
+// flagValues returns the values a flag accepts, or nil if they can't be
+// listed, in which case shells fall back to file names.
+func flagValues(name string) []string {
+	switch name {
+	case "output":
+		return []string{"json", "csv", "table", "text"}
+	case "position":
+		return []string{"address", "pluscode", "w3w"}
+	case "profile":
+		var names []string
+		for name := range rankProfiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names
+	case "channel":
+		return []string{"email", "pushover", "slack", "webhook"}
+	case "alert":
+		return []string{"countdown", "daemon"}
+	case "provider":
+		return bikeshare.Providers()
+	case "location":
+		var names []string
+		for name := range cfg.locations {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names
+	case "hub":
+		return storedHubNames()
+	}
+	return nil
+}
+
+// storedHubNames returns the names of the hubs in the history stats reads
+// by default, or nil if there is no store.
+func storedHubNames() []string {
+	st, err := openStore()
+	if err != nil || st == nil {
+		return nil
+	}
+	now := clk.Now()
+	snaps, err := st.Snapshots(now.Add(-defaultStatsSince), now.Add(time.Nanosecond))
+	if err != nil {
+		return nil
+	}
+	seen := map[string]bool{}
+	var names []string
+	for _, snap := range snaps {
+		for _, station := range snap.Stations {
+			if !seen[station.Name] {
+				seen[station.Name] = true
+				names = append(names, station.Name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// argValues returns the positional arguments a command accepts, if they
+// can be listed.
+func argValues(name string) []string {
+	switch name {
+	case "alert":
+		return []string{"new"}
+	case "completion":
+		return []string{"bash", "zsh", "fish"}
+	case "notify":
+		return []string{"test"}
+	case "help":
+		var names []string
+		for _, cmd := range commands {
+			names = append(names, cmd.name)
+		}
+		return names
+	}
+	return nil
+}
+
+// isBoolFlag reports whether f is a flag that takes no value.
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
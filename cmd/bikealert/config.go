@@ -0,0 +1,397 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/themichaellai/bikealert/internal/units"
+	"github.com/themichaellai/bikealert/notify"
+)
+
+// cfg is the loaded config file. Flags and env vars take precedence over
+// everything in it.
+var cfg config
+
+// configFile is the YAML config file shared by the CLI and the daemon:
+//
+//	network: "8"
+//	location: home
+//	locations:
+//	  home: {latitude: 37.776, longitude: -122.418}
+//	  work: {latitude: 37.789, longitude: -122.401}
+//	poll_interval: 2m
+//	rank_schedule: night=21:00-06:00,day=06:00-21:00
+//	alerts: {max_distance: 0.3mi, min_battery: 50%, group_size: 2}
+//	notify:
+//	  - {type: slack, url: "https://hooks.slack.com/services/..."}
+//
+// JSON is also YAML, so config files written as JSON still load.
+type configFile struct {
+	Provider string `yaml:"provider"`
+	Network  string `yaml:"network"`
+	// Settings are extra provider settings, as if from env vars prefixed
+	// with the provider's name.
+	Settings map[string]string `yaml:"settings"`
+	// Location names the entry in Locations to use when no origin is
+	// given.
+	Location     string                    `yaml:"location"`
+	Locations    map[string]configLocation `yaml:"locations"`
+	PollInterval string                    `yaml:"poll_interval"`
+	// RankSchedule stands in for $RANK_SCHEDULE.
+	RankSchedule string          `yaml:"rank_schedule"`
+	Alerts       configAlerts    `yaml:"alerts"`
+	Notify       []notify.Config `yaml:"notify"`
+	// StoreDir, StoreRetention and StoreRadius stand in for $STORE_DIR,
+	// $STORE_RETENTION and $STORE_RADIUS.
+	StoreDir       string `yaml:"store_dir"`
+	StoreRetention string `yaml:"store_retention"`
+	StoreRadius    string `yaml:"store_radius"`
+}
+
+type configAlerts struct {
+	MaxDistance string `yaml:"max_distance,omitempty"`
+	MinBattery  string `yaml:"min_battery,omitempty"`
+	GroupSize   int    `yaml:"group_size,omitempty"`
+}
+
+type configLocation struct {
+	Latitude  float64 `yaml:"latitude"`
+	Longitude float64 `yaml:"longitude"`
+}
+
+// config is a parsed configFile. Zero values mean unset.
+type config struct {
+	provider     string
+	network      string
+	settings     map[string]string
+	location     string
+	locations    map[string]configLocation
+	pollInterval time.Duration
+	rankSchedule []rankWindow
+	limits       alertLimits
+	// alerts are the limits as written, for editing.
+	alerts   configAlerts
+	notify   []notify.Config
+	storeDir string
+	// storeRetention is nil if unset, since 0 means keep forever.
+	storeRetention *time.Duration
+	// storeRadius is in miles, and nil if unset, since 0 means the whole
+	// network.
+	storeRadius *float64
+}
+
+// configFlag is set by --config.
+var configFlag string
+
+// configPath returns the config file to use: --config, $BIKEALERT_CONFIG,
+// or else bikealert/config.yaml in the user's config directory. The last
+// is optional, so explicit reports whether the file must exist.
+func configPath() (path string, explicit bool, err error) {
+	if configFlag != "" {
+		return configFlag, true, nil
+	}
+	if path := os.Getenv("BIKEALERT_CONFIG"); path != "" {
+		return path, true, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", false, err
+	}
+	path = filepath.Join(dir, "bikealert", "config.yaml")
+	// Earlier versions wrote config.json, which still loads as YAML, so
+	// keep using it until there is a config.yaml.
+	legacy := filepath.Join(dir, "bikealert", "config.json")
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		if _, err := os.Stat(legacy); err == nil {
+			return legacy, false, nil
+		}
+	}
+	return path, false, nil
+}
+
+// loadConfig reads the config file named by configPath, returning an
+// empty config if it is the default and doesn't exist.
+func loadConfig() (config, error) {
+	path, explicit, err := configPath()
+	if err != nil {
+		return config{}, fmt.Errorf("error finding config: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if !explicit && errors.Is(err, fs.ErrNotExist) {
+		return config{}, nil
+	} else if err != nil {
+		return config{}, fmt.Errorf("error reading config: %w", err)
+	}
+
+	var file configFile
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil && err != io.EOF {
+		return config{}, configError(path, data, err)
+	}
+	c, err := file.parse()
+	if err != nil {
+		return config{}, configError(path, data, err)
+	}
+	return c, nil
+}
+
+// configFieldError is an invalid value in the config file at path, such as
+// "alerts.max_distance".
+type configFieldError struct {
+	path string
+	err  error
+}
+
+func (e *configFieldError) Error() string {
+	return e.path + ": " + e.err.Error()
+}
+
+func (e *configFieldError) Unwrap() error {
+	return e.err
+}
+
+// yamlErrorLine matches the line number yaml puts in its error messages.
+var yamlErrorLine = regexp.MustCompile(`line (\d+):`)
+
+// configError describes an error parsing the config file at path, with the
+// line, and column when known, in data it concerns.
+func configError(path string, data []byte, err error) error {
+	var line, column int
+	var fieldErr *configFieldError
+	if errors.As(err, &fieldErr) {
+		// The file decoded, so this only fails to find the key if data
+		// is empty.
+		var doc yaml.Node
+		if yaml.Unmarshal(data, &doc) == nil {
+			if key := findConfigKey(&doc, fieldErr.path); key != nil {
+				line, column = key.Line, key.Column
+			}
+		}
+	} else if m := yamlErrorLine.FindStringSubmatch(err.Error()); m != nil {
+		// Syntax errors and the first of any type errors name a line.
+		line, _ = strconv.Atoi(m[1])
+	}
+	switch {
+	case column > 0:
+		return fmt.Errorf("error parsing config %s:%d:%d: %w", path, line, column, err)
+	case line > 0:
+		return fmt.Errorf("error parsing config %s:%d: %w", path, line, err)
+	default:
+		return fmt.Errorf("error parsing config %s: %w", path, err)
+	}
+}
+
+// findConfigKey returns the key node at a dotted path such as
+// "alerts.max_distance" in a parsed YAML document, or nil if there isn't
+// one. Sequence items are numbered, as in "notify.0.url".
+func findConfigKey(node *yaml.Node, path string) *yaml.Node {
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	name, rest, _ := strings.Cut(path, ".")
+	var key, val *yaml.Node
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == name {
+				key, val = node.Content[i], node.Content[i+1]
+			}
+		}
+	case yaml.SequenceNode:
+		if i, err := strconv.Atoi(name); err == nil && i >= 0 && i < len(node.Content) {
+			key, val = node.Content[i], node.Content[i]
+		}
+	}
+	if key == nil || rest == "" {
+		return key
+	}
+	return findConfigKey(val, rest)
+}
+
+func (f configFile) parse() (config, error) {
+	c := config{
+		provider:  f.Provider,
+		network:   f.Network,
+		settings:  f.Settings,
+		location:  f.Location,
+		locations: f.Locations,
+		notify:    f.Notify,
+		storeDir:  f.StoreDir,
+		alerts:    f.Alerts,
+	}
+	if c.location != "" {
+		if _, ok := c.locations[c.location]; !ok {
+			return config{}, &configFieldError{"location", fmt.Errorf("%q is not in locations", c.location)}
+		}
+	}
+	var err error
+	if f.PollInterval != "" {
+		if c.pollInterval, err = time.ParseDuration(f.PollInterval); err != nil {
+			return config{}, &configFieldError{"poll_interval", err}
+		}
+		if c.pollInterval <= 0 {
+			return config{}, &configFieldError{"poll_interval", errors.New("must be positive")}
+		}
+	}
+	if f.RankSchedule != "" {
+		if c.rankSchedule, err = parseRankSchedule(f.RankSchedule); err != nil {
+			return config{}, &configFieldError{"rank_schedule", err}
+		}
+	}
+	if f.StoreRetention != "" {
+		retention, err := time.ParseDuration(f.StoreRetention)
+		if err != nil {
+			return config{}, &configFieldError{"store_retention", err}
+		}
+		c.storeRetention = &retention
+	}
+	if f.StoreRadius != "" {
+		radius, err := units.ParseDistance(f.StoreRadius)
+		if err != nil {
+			return config{}, &configFieldError{"store_radius", err}
+		}
+		c.storeRadius = &radius
+	}
+	if f.Alerts.MaxDistance != "" {
+		if c.limits.maxDistance, err = units.ParseDistance(f.Alerts.MaxDistance); err != nil {
+			return config{}, &configFieldError{"alerts.max_distance", err}
+		}
+	}
+	if f.Alerts.MinBattery != "" {
+		if c.limits.minBattery, err = units.ParsePercent(f.Alerts.MinBattery); err != nil {
+			return config{}, &configFieldError{"alerts.min_battery", err}
+		}
+	}
+	if f.Alerts.GroupSize < 0 {
+		return config{}, &configFieldError{"alerts.group_size", errors.New("must be at least 1")}
+	}
+	c.limits.groupSize = f.Alerts.GroupSize
+	return c, nil
+}
+
+// updateConfigFile sets top-level fields in the config file, creating it
+// if needed, and returns its path. Other fields, and comments, are kept as
+// they are.
+func updateConfigFile(fields map[string]interface{}) (string, error) {
+	path, _, err := configPath()
+	if err != nil {
+		return "", err
+	}
+	var doc yaml.Node
+	data, err := os.ReadFile(path)
+	if err == nil {
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return "", fmt.Errorf("error parsing config %s: %w", path, err)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("error reading config: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return "", fmt.Errorf("error parsing config %s: not a mapping", path)
+	}
+	// Sort new keys so they are added in the same order every time.
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		var val yaml.Node
+		if err := val.Encode(fields[key]); err != nil {
+			return "", err
+		}
+		if old := findConfigKey(root, key); old != nil {
+			for i := 0; i+1 < len(root.Content); i += 2 {
+				if root.Content[i] == old {
+					root.Content[i+1] = &val
+				}
+			}
+			continue
+		}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, &val)
+	}
+	// A file written as JSON would come back as one line of flow style,
+	// so write it out as plain YAML instead.
+	if root.Style&yaml.FlowStyle != 0 {
+		clearStyle(root)
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return "", err
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+
+	// The file may hold notifier credentials, so keep it private.
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("error writing config: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o600); err != nil {
+		return "", fmt.Errorf("error writing config: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return "", fmt.Errorf("error writing config: %w", err)
+	}
+	return path, nil
+}
+
+// clearStyle resets node and everything under it to the default style,
+// which quotes only scalars that need it.
+func clearStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearStyle(child)
+	}
+}
+
+// lookupLocation returns the coordinates of a named location.
+func (c config) lookupLocation(name string) (float64, float64, error) {
+	loc, ok := c.locations[name]
+	if !ok {
+		return 0, 0, fmt.Errorf("no location named %q in config", name)
+	}
+	return loc.Latitude, loc.Longitude, nil
+}
+
+// interval returns the configured poll interval, or def if there isn't
+// one.
+func (c config) interval(def time.Duration) time.Duration {
+	if c.pollInterval == 0 {
+		return def
+	}
+	return c.pollInterval
+}
+
+// pollInterval returns $POLL_INTERVAL, the config's poll_interval or def.
+// It must be positive, or polls would run back to back.
+func pollInterval(def time.Duration) (time.Duration, error) {
+	interval, err := getEnvDuration("POLL_INTERVAL", cfg.interval(def))
+	if err != nil {
+		return 0, err
+	}
+	if interval <= 0 {
+		return 0, fmt.Errorf("envvar \"POLL_INTERVAL\" must be positive")
+	}
+	return interval, nil
+}
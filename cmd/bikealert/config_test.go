@@ -0,0 +1,162 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPollInterval(t *testing.T) {
+	tests := []struct {
+		env     string
+		want    time.Duration
+		wantErr bool
+	}{
+		{env: "", want: time.Minute},
+		{env: "45s", want: 45 * time.Second},
+		{env: "0s", wantErr: true},
+		{env: "-30s", wantErr: true},
+		{env: "soon", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			if tt.env != "" {
+				t.Setenv("POLL_INTERVAL", tt.env)
+			}
+			got, err := pollInterval(time.Minute)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("pollInterval() error = %v, want error %t", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("pollInterval() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// writeTestConfig writes data to a config file and points --config at it.
+func writeTestConfig(t *testing.T, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	configFlag = path
+	t.Cleanup(func() { configFlag = "" })
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		// wantErr is the start of the error after the file name, or
+		// empty for none.
+		wantErr string
+	}{
+		{name: "empty", data: ""},
+		{
+			name: "yaml",
+			data: "network: \"8\"\nlocation: home\nlocations:\n  home: {latitude: 37.776, longitude: -122.418}\nalerts:\n  max_distance: 0.3mi\n",
+		},
+		{
+			name: "json",
+			data: "{\n\t\"network\": \"8\",\n\t\"alerts\": {\"max_distance\": \"0.3mi\"}\n}\n",
+		},
+		{
+			name:    "invalid value",
+			data:    "network: \"8\"\nalerts:\n  max_distance: far\n",
+			wantErr: ":3:3: alerts.max_distance:",
+		},
+		{
+			name:    "invalid value in json",
+			data:    "{\n  \"poll_interval\": \"0s\"\n}\n",
+			wantErr: ":2:3: poll_interval:",
+		},
+		{
+			name:    "missing location",
+			data:    "location: home\n",
+			wantErr: ":1:1: location:",
+		},
+		{
+			name:    "unknown field",
+			data:    "network: \"8\"\nalert:\n  max_distance: 0.3mi\n",
+			wantErr: ":2: ",
+		},
+		{
+			name:    "wrong type",
+			data:    "alerts:\n  group_size: two\n",
+			wantErr: ":2: ",
+		},
+		{
+			name:    "syntax error",
+			data:    "network: \"8\"\nlocations:\n\thome: {}\n",
+			wantErr: ":3: ",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestConfig(t, tt.data)
+			_, err := loadConfig()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("loadConfig() error = %v", err)
+				}
+				return
+			}
+			want := "error parsing config " + path + tt.wantErr
+			if err == nil || !strings.HasPrefix(err.Error(), want) {
+				t.Errorf("loadConfig() error = %v, want prefix %q", err, want)
+			}
+		})
+	}
+}
+
+func TestUpdateConfigFile(t *testing.T) {
+	tests := []struct {
+		name, data string
+		want       []string
+	}{
+		{
+			name: "yaml",
+			data: "# home first\nnetwork: \"8\"\nalerts:\n  max_distance: 1mi\n",
+			want: []string{"# home first\n", "network: \"8\"\n", "alerts:\n  max_distance: 0.3mi\n"},
+		},
+		{
+			name: "json",
+			data: "{\"network\": \"8\", \"poll_interval\": \"2m\"}\n",
+			want: []string{"network: \"8\"\n", "poll_interval: 2m\n", "alerts:\n  max_distance: 0.3mi\n"},
+		},
+		{
+			name: "empty",
+			data: "",
+			want: []string{"alerts:\n  max_distance: 0.3mi\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestConfig(t, tt.data)
+			if _, err := updateConfigFile(map[string]interface{}{"alerts": configAlerts{MaxDistance: "0.3mi"}}); err != nil {
+				t.Fatalf("updateConfigFile() error = %v", err)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(data), want) {
+					t.Errorf("config file is\n%s\nwant it to contain %q", data, want)
+				}
+			}
+			c, err := loadConfig()
+			if err != nil {
+				t.Fatalf("loadConfig() error = %v", err)
+			}
+			if c.limits.maxDistance != 0.3 {
+				t.Errorf("loadConfig() max distance = %v, want 0.3", c.limits.maxDistance)
+			}
+		})
+	}
+}
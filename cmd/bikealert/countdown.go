@@ -0,0 +1,303 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/themichaellai/bikealert/bikeshare"
+	"github.com/themichaellai/bikealert/geo"
+	"github.com/themichaellai/bikealert/jump"
+	"github.com/themichaellai/bikealert/notify"
+)
+
+const defaultCountdownInterval = 30 * time.Second
+
+// countdownJitter is the largest fraction of the poll interval added or
+// removed at random, so polls don't land on a fixed schedule.
+const countdownJitter = 0.2
+
+// runCountdown polls every $POLL_INTERVAL until the departure time in
+// $DEPART, showing the best bike each time. It rings the terminal bell
+// when fewer than $GROUP_SIZE bikes (default 1) meet $MAX_DISTANCE and
+// $MIN_BATTERY.
+func runCountdown(args []string) error {
+	latitude, longitude, err := getOrigin()
+	if err != nil {
+		return err
+	}
+	departure, err := getDeparture()
+	if err != nil {
+		return err
+	}
+	limits, err := loadAlertLimits()
+	if err != nil {
+		return err
+	}
+	interval, err := pollInterval(defaultCountdownInterval)
+	if err != nil {
+		return err
+	}
+	ignoredBikes, err := loadIgnoredBikes()
+	if err != nil {
+		return err
+	}
+	notifier, err := loadNotifier()
+	if err != nil {
+		return err
+	}
+
+	provider, err := newPollingProvider()
+	if err != nil {
+		return err
+	}
+	hist, err := openHistory()
+	if err != nil {
+		return err
+	}
+	counter, counts := providerStats(provider)
+	wasAcceptable := true
+	for {
+		wait := jitter(interval, countdownJitter)
+
+		remaining := departure.Sub(clk.Now())
+		if remaining <= 0 {
+			if jsonLines {
+				emitEvent(event{Type: eventDepart, RemainingSeconds: remainingSeconds(0)})
+			} else {
+				fmt.Println("time to leave")
+			}
+			return nil
+		}
+
+		var before jump.Stats
+		if counts {
+			before = counter.Stats()
+		}
+		vehicles, stations, err := hist.fetch(context.Background(), provider, latitude, longitude)
+		vehicles = removeIgnored(vehicles, ignoredBikes)
+		if counts {
+			logStatsDelta(before, counter.Stats())
+		}
+		if err == nil {
+			hist.record(vehicles, stations, latitude, longitude)
+		}
+		if err != nil {
+			if jsonLines {
+				emitEvent(event{Type: eventError, RemainingSeconds: remainingSeconds(remaining), Error: err.Error()})
+			} else {
+				fmt.Fprintf(os.Stderr, "error fetching bikes: %s\n", err.Error())
+			}
+			var rateLimited *jump.RateLimitedError
+			if errors.As(err, &rateLimited) && rateLimited.RetryAfter > wait {
+				wait = rateLimited.RetryAfter
+			}
+		} else if len(vehicles) == 0 {
+			if jsonLines {
+				emitEvent(event{Type: eventPoll, RemainingSeconds: remainingSeconds(remaining)})
+			} else {
+				fmt.Printf("leave in %s; no bikes found\n", formatRemaining(remaining))
+			}
+		} else {
+			sortVehicles(vehicles, latitude, longitude)
+			best := vehicles[0]
+			dist := geo.Distance(latitude, longitude, best.Latitude, best.Longitude)
+			acceptableBikes := limits.countAcceptable(vehicles, latitude, longitude)
+			if jsonLines {
+				emitEvent(event{
+					Type:             eventPoll,
+					RemainingSeconds: remainingSeconds(remaining),
+					Bike:             newEventVehicle(best, latitude, longitude),
+					AcceptableBikes:  &acceptableBikes,
+				})
+			} else {
+				var group string
+				if limits.groupSize > 1 {
+					group = fmt.Sprintf("; %d of %d bikes needed are within limits", acceptableBikes, limits.groupSize)
+				}
+				fmt.Printf("leave in %s; current best bike is %0.2fmi %s, %s%s\n",
+					formatRemaining(remaining),
+					dist,
+					geo.CompassPoint(geo.Bearing(latitude, longitude, best.Latitude, best.Longitude)),
+					formatBattery(best.BatteryLevel),
+					group,
+				)
+			}
+
+			acceptable := acceptableBikes >= limits.groupSize
+			explainUnknownBattery(limits, vehicles, latitude, longitude)
+			explainf("best bike %s: %0.2f mi (max %s), %s battery (min %0.0f%%); %d bikes within limits (need %d), acceptable=%t, previously acceptable=%t",
+				best.Name, dist, formatLimit(limits.maxDistance), formatBattery(best.BatteryLevel), limits.minBattery,
+				acceptableBikes, limits.groupSize, acceptable, wasAcceptable)
+			if wasAcceptable && !acceptable {
+				sendNotification(notifier, alertMessage(best, dist, acceptableBikes, limits.groupSize, remaining))
+				if jsonLines {
+					emitEvent(event{
+						Type:             eventAlert,
+						RemainingSeconds: remainingSeconds(remaining),
+						Bike:             newEventVehicle(best, latitude, longitude),
+						AcceptableBikes:  &acceptableBikes,
+					})
+				} else if limits.groupSize > 1 {
+					fmt.Printf("\aALERT: only %d of %d bikes needed are within limits\n",
+						acceptableBikes, limits.groupSize)
+				} else {
+					fmt.Printf("\aALERT: best bike is now %0.2fmi away with %s battery\n",
+						dist, formatBattery(best.BatteryLevel))
+				}
+			}
+			wasAcceptable = acceptable
+		}
+
+		if remaining < wait {
+			wait = remaining
+		}
+		clk.Sleep(wait)
+	}
+}
+
+// alertMessage describes a countdown alert for notifiers.
+func alertMessage(best bikeshare.Vehicle, dist float64, acceptableBikes, groupSize int, remaining time.Duration) notify.Message {
+	body := fmt.Sprintf("Best bike %s is %0.2fmi away with %s battery.", best.Name, dist, formatBattery(best.BatteryLevel))
+	if groupSize > 1 {
+		body = fmt.Sprintf("Only %d of %d bikes needed are within limits. %s", acceptableBikes, groupSize, body)
+	}
+	return notify.Message{
+		Title: fmt.Sprintf("Bikes running low, leave in %s", formatRemaining(remaining)),
+		Body:  body,
+	}
+}
+
+// alertLimits decide which bikes are good enough, and how many are
+// needed, before an alert fires.
+type alertLimits struct {
+	// maxDistance is in miles, or 0 for no limit.
+	maxDistance float64
+	minBattery  float64
+	groupSize   int
+}
+
+// loadAlertLimits reads $MAX_DISTANCE, $MIN_BATTERY and $GROUP_SIZE,
+// falling back to the config file's alerts.
+func loadAlertLimits() (alertLimits, error) {
+	maxDistance, err := getEnvDistance("MAX_DISTANCE", cfg.limits.maxDistance)
+	if err != nil {
+		return alertLimits{}, err
+	}
+	minBattery, err := getEnvPercent("MIN_BATTERY", cfg.limits.minBattery)
+	if err != nil {
+		return alertLimits{}, err
+	}
+	defaultGroupSize := 1
+	if cfg.limits.groupSize != 0 {
+		defaultGroupSize = cfg.limits.groupSize
+	}
+	groupSize, err := getEnvInt("GROUP_SIZE", defaultGroupSize)
+	if err != nil {
+		return alertLimits{}, err
+	}
+	if groupSize < 1 {
+		return alertLimits{}, fmt.Errorf("envvar \"GROUP_SIZE\" must be at least 1")
+	}
+	return alertLimits{maxDistance: maxDistance, minBattery: minBattery, groupSize: groupSize}, nil
+}
+
+// acceptable reports whether a bike is within the distance limit and has
+// enough battery. The limit is physical distance whatever the ranking
+// profile, which only decides the order bikes are shown in. Bikes with
+// unknown battery only pass when there is no battery minimum, since
+// nothing says they would get you there; see countUnknownBattery.
+func (l alertLimits) acceptable(vehicle bikeshare.Vehicle, latitude, longitude float64) bool {
+	return l.withinDistance(vehicle, latitude, longitude) &&
+		(l.minBattery == 0 || float64(vehicle.BatteryLevel) >= l.minBattery)
+}
+
+func (l alertLimits) withinDistance(vehicle bikeshare.Vehicle, latitude, longitude float64) bool {
+	return l.maxDistance == 0 ||
+		geo.Distance(latitude, longitude, vehicle.Latitude, vehicle.Longitude) <= l.maxDistance
+}
+
+// countAcceptable returns how many vehicles are acceptable.
+func (l alertLimits) countAcceptable(vehicles []bikeshare.Vehicle, latitude, longitude float64) int {
+	n := 0
+	for _, vehicle := range vehicles {
+		if l.acceptable(vehicle, latitude, longitude) {
+			n++
+		}
+	}
+	return n
+}
+
+// countUnknownBattery returns how many vehicles within the distance limit
+// fail only because their battery level is unknown, so that can be said
+// rather than leaving them out silently.
+func (l alertLimits) countUnknownBattery(vehicles []bikeshare.Vehicle, latitude, longitude float64) int {
+	if l.minBattery == 0 {
+		return 0
+	}
+	n := 0
+	for _, vehicle := range vehicles {
+		if vehicle.BatteryLevel < 0 && l.withinDistance(vehicle, latitude, longitude) {
+			n++
+		}
+	}
+	return n
+}
+
+// explainUnknownBattery notes when bikes were left out of the count only
+// for having an unknown battery level.
+func explainUnknownBattery(limits alertLimits, vehicles []bikeshare.Vehicle, latitude, longitude float64) {
+	if n := limits.countUnknownBattery(vehicles, latitude, longitude); n > 0 {
+		explainf("%d bikes within distance have an unknown battery level, so don't count toward MIN_BATTERY", n)
+	}
+}
+
+// getDeparture parses $DEPART, a time of day such as "08:45", as the next
+// occurrence of that time today.
+func getDeparture() (time.Time, error) {
+	val, set := os.LookupEnv("DEPART")
+	if !set {
+		return time.Time{}, fmt.Errorf("envvar \"DEPART\" not set")
+	}
+	clock, err := time.Parse("15:04", val)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error parsing env var \"DEPART\" as time of day: %w", err)
+	}
+	now := clk.Now()
+	departure := time.Date(now.Year(), now.Month(), now.Day(),
+		clock.Hour(), clock.Minute(), 0, 0, now.Location())
+	if departure.Before(now) {
+		return time.Time{}, fmt.Errorf("departure time %s has already passed", val)
+	}
+	return departure, nil
+}
+
+// statsDelta returns the counters accumulated between two snapshots.
+func statsDelta(before, after jump.Stats) jump.Stats {
+	return jump.Stats{
+		Requests:       after.Requests - before.Requests,
+		BytesRead:      after.BytesRead - before.BytesRead,
+		ReusedConns:    after.ReusedConns - before.ReusedConns,
+		HTTP2Responses: after.HTTP2Responses - before.HTTP2Responses,
+	}
+}
+
+// jitter returns d randomly adjusted by up to the given fraction.
+func jitter(d time.Duration, fraction float64) time.Duration {
+	return time.Duration(float64(d) * (1 + fraction*(2*rng.Float64()-1)))
+}
+
+// formatLimit formats a MAX_DISTANCE value, where 0 means no limit.
+func formatLimit(maxDistance float64) string {
+	if maxDistance == 0 {
+		return "none"
+	}
+	return fmt.Sprintf("%0.2f mi", maxDistance)
+}
+
+// formatRemaining formats d as whole minutes, e.g. "12m".
+func formatRemaining(d time.Duration) string {
+	return fmt.Sprintf("%dm", int(d.Round(time.Minute).Minutes()))
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/themichaellai/bikealert/bikeshare"
+	"github.com/themichaellai/bikealert/internal/units"
+)
+
+const testLatitude, testLongitude = 37.776, -122.418
+
+// metersNorth returns a vehicle the given distance north of the test
+// origin.
+func metersNorth(meters float64, battery int) bikeshare.Vehicle {
+	return bikeshare.Vehicle{
+		Latitude:     testLatitude + meters/111195,
+		Longitude:    testLongitude,
+		BatteryLevel: battery,
+	}
+}
+
+func mustDistance(t *testing.T, s string) float64 {
+	t.Helper()
+	d, err := units.ParseDistance(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestAlertLimitsAcceptable(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxDistance string
+		minBattery  float64
+		vehicle     bikeshare.Vehicle
+		want        bool
+	}{
+		{name: "no limits", maxDistance: "0", vehicle: metersNorth(5000, -1), want: true},
+		{name: "within distance", maxDistance: "500m", vehicle: metersNorth(400, 100), want: true},
+		{name: "beyond distance", maxDistance: "500m", vehicle: metersNorth(600, 100), want: false},
+		// Under the day profile an empty bike 400m away ranks as if it
+		// were 600m away. The limit is still physical distance.
+		{name: "empty bike within distance", maxDistance: "500m", vehicle: metersNorth(400, 0), want: true},
+		{name: "enough battery", maxDistance: "500m", minBattery: 50, vehicle: metersNorth(100, 50), want: true},
+		{name: "too little battery", maxDistance: "500m", minBattery: 50, vehicle: metersNorth(100, 49), want: false},
+		{name: "unknown battery with a minimum", maxDistance: "500m", minBattery: 50, vehicle: metersNorth(100, -1), want: false},
+		{name: "unknown battery without a minimum", maxDistance: "500m", vehicle: metersNorth(100, -1), want: true},
+	}
+	defer func(name string) { rankProfileName = name }(rankProfileName)
+	for _, profile := range []string{"distance", "day", "night"} {
+		rankProfileName = profile
+		for _, tt := range tests {
+			t.Run(profile+"/"+tt.name, func(t *testing.T) {
+				limits := alertLimits{maxDistance: mustDistance(t, tt.maxDistance), minBattery: tt.minBattery, groupSize: 1}
+				if got := limits.acceptable(tt.vehicle, testLatitude, testLongitude); got != tt.want {
+					t.Errorf("acceptable() = %t, want %t", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestAlertLimitsCounts(t *testing.T) {
+	vehicles := []bikeshare.Vehicle{
+		metersNorth(100, 80),
+		metersNorth(200, 10),
+		metersNorth(300, -1),
+		metersNorth(400, -1),
+		metersNorth(900, 90),
+	}
+	tests := []struct {
+		maxDistance     string
+		minBattery      float64
+		wantAcceptable  int
+		wantUnknownOnly int
+	}{
+		{maxDistance: "0", wantAcceptable: 5},
+		{maxDistance: "500m", wantAcceptable: 4},
+		{maxDistance: "500m", minBattery: 50, wantAcceptable: 1, wantUnknownOnly: 2},
+		{maxDistance: "250m", minBattery: 50, wantAcceptable: 1, wantUnknownOnly: 0},
+	}
+	for _, tt := range tests {
+		limits := alertLimits{maxDistance: mustDistance(t, tt.maxDistance), minBattery: tt.minBattery, groupSize: 1}
+		if got := limits.countAcceptable(vehicles, testLatitude, testLongitude); got != tt.wantAcceptable {
+			t.Errorf("countAcceptable(%s, %v%%) = %d, want %d", tt.maxDistance, tt.minBattery, got, tt.wantAcceptable)
+		}
+		if got := limits.countUnknownBattery(vehicles, testLatitude, testLongitude); got != tt.wantUnknownOnly {
+			t.Errorf("countUnknownBattery(%s, %v%%) = %d, want %d", tt.maxDistance, tt.minBattery, got, tt.wantUnknownOnly)
+		}
+	}
+}
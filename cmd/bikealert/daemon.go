@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/themichaellai/bikealert/bikeshare"
+	"github.com/themichaellai/bikealert/jump"
+	"github.com/themichaellai/bikealert/notify"
+)
+
+const defaultDaemonInterval = time.Minute
+
+// maxDaemonBackoff caps how long the daemon waits after repeated errors.
+const maxDaemonBackoff = 15 * time.Minute
+
+var (
+	daemonFlags    = newLocationFlagSet("daemon")
+	daemonInterval = daemonFlags.Duration("interval", 0,
+		"how often to poll (default $POLL_INTERVAL, the config's poll_interval or 1m)")
+	daemonHealthAddr = daemonFlags.String("health-addr", "",
+		"serve /livez and /readyz on this address, e.g. :8080 (default $HEALTH_ADDR)")
+)
+
+// runDaemon polls until interrupted, notifying whenever the number of
+// bikes meeting $MAX_DISTANCE and $MIN_BATTERY falls below $GROUP_SIZE
+// and again when it recovers. Provider errors back off exponentially.
+func runDaemon(args []string) error {
+	if *daemonInterval < 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+	interval := *daemonInterval
+	if interval == 0 {
+		var err error
+		if interval, err = pollInterval(defaultDaemonInterval); err != nil {
+			return err
+		}
+	}
+	healthAddr := *daemonHealthAddr
+	if healthAddr == "" {
+		healthAddr = os.Getenv("HEALTH_ADDR")
+	}
+
+	latitude, longitude, err := getOrigin()
+	if err != nil {
+		return err
+	}
+	limits, err := loadAlertLimits()
+	if err != nil {
+		return err
+	}
+	ignoredBikes, err := loadIgnoredBikes()
+	if err != nil {
+		return err
+	}
+	notifier, err := loadNotifier()
+	if err != nil {
+		return err
+	}
+	provider, err := newPollingProvider()
+	if err != nil {
+		return err
+	}
+	hist, err := openHistory()
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Polls older than a few intervals mean the daemon is stuck or the
+	// provider is down.
+	probes := &healthProbes{maxAge: 3 * interval}
+	var server *http.Server
+	if healthAddr != "" {
+		server, err = probes.listen(healthAddr)
+		if err != nil {
+			return err
+		}
+		daemonf("serving health probes on %s", healthAddr)
+	}
+
+	daemonf("polling every %s", interval)
+	wasAcceptable := true
+	failures := 0
+	for ctx.Err() == nil {
+		wait := jitter(interval, countdownJitter)
+
+		vehicles, stations, err := hist.fetch(ctx, provider, latitude, longitude)
+		if err != nil && ctx.Err() != nil {
+			break
+		} else if err != nil {
+			failures++
+			wait = backoff(interval, failures)
+			var rateLimited *jump.RateLimitedError
+			if errors.As(err, &rateLimited) && rateLimited.RetryAfter > wait {
+				wait = rateLimited.RetryAfter
+			}
+			daemonf("error fetching bikes (%d in a row), retrying in %s: %s", failures, wait.Round(100*time.Millisecond), err.Error())
+			if jsonLines {
+				emitEvent(event{Type: eventError, Error: err.Error()})
+			}
+		} else {
+			failures = 0
+			probes.polled(clk.Now())
+			vehicles = removeIgnored(vehicles, ignoredBikes)
+			hist.record(vehicles, stations, latitude, longitude)
+			acceptableBikes := limits.countAcceptable(vehicles, latitude, longitude)
+			acceptable := acceptableBikes >= limits.groupSize
+			explainUnknownBattery(limits, vehicles, latitude, longitude)
+			explainf("%d bikes within limits (need %d), acceptable=%t, previously acceptable=%t",
+				acceptableBikes, limits.groupSize, acceptable, wasAcceptable)
+			if jsonLines {
+				emitDaemonEvent(eventPoll, vehicles, acceptableBikes, latitude, longitude)
+			}
+			if acceptable != wasAcceptable {
+				msg := daemonMessage(acceptableBikes, limits.groupSize, acceptable)
+				daemonf("%s", msg.Body)
+				sendNotification(notifier, msg)
+				if jsonLines && acceptable {
+					emitDaemonEvent(eventRecover, vehicles, acceptableBikes, latitude, longitude)
+				} else if jsonLines {
+					emitDaemonEvent(eventAlert, vehicles, acceptableBikes, latitude, longitude)
+				}
+			}
+			wasAcceptable = acceptable
+		}
+
+		sleepContext(ctx, wait)
+	}
+
+	daemonf("shutting down")
+	if server != nil {
+		probes.stopping()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), healthShutdownTimeout)
+		defer cancel()
+		return server.Shutdown(shutdownCtx)
+	}
+	return nil
+}
+
+// emitDaemonEvent writes a --jsonl event for a daemon poll, with the best
+// bike if there is one.
+func emitDaemonEvent(eventType string, vehicles []bikeshare.Vehicle, acceptableBikes int, latitude, longitude float64) {
+	e := event{Type: eventType, AcceptableBikes: &acceptableBikes}
+	if len(vehicles) > 0 {
+		sorted := append([]bikeshare.Vehicle(nil), vehicles...)
+		sortVehicles(sorted, latitude, longitude)
+		e.Bike = newEventVehicle(sorted[0], latitude, longitude)
+	}
+	emitEvent(e)
+}
+
+// daemonMessage describes a change in how many bikes are within limits.
+func daemonMessage(acceptableBikes, groupSize int, acceptable bool) notify.Message {
+	if acceptable {
+		return notify.Message{
+			Title: "Bikes available again",
+			Body:  fmt.Sprintf("%d bikes are within limits again.", acceptableBikes),
+		}
+	}
+	return notify.Message{
+		Title: "Bikes running low",
+		Body:  fmt.Sprintf("Only %d of %d bikes needed are within limits.", acceptableBikes, groupSize),
+	}
+}
+
+// backoff returns how long to wait after the given number of consecutive
+// failures: the interval doubled for each one, up to maxDaemonBackoff.
+func backoff(interval time.Duration, failures int) time.Duration {
+	wait := interval
+	for i := 1; i < failures && wait < maxDaemonBackoff; i++ {
+		wait *= 2
+	}
+	if wait > maxDaemonBackoff {
+		wait = maxDaemonBackoff
+	}
+	return jitter(wait, countdownJitter)
+}
+
+// sleepContext sleeps on clk for d, returning early with ctx's error if it
+// is cancelled first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	select {
+	case <-clk.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// daemonf prints a timestamped line to stdout, or to stderr with --jsonl
+// so stdout is only events.
+func daemonf(format string, args ...interface{}) {
+	out := os.Stdout
+	if jsonLines {
+		out = os.Stderr
+	}
+	fmt.Fprintf(out, "%s "+format+"\n", append([]interface{}{clk.Now().Format(time.RFC3339)}, args...)...)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/themichaellai/bikealert/clock"
+)
+
+func TestSleepContext(t *testing.T) {
+	defer func(c clock.Clock) { clk = c }(clk)
+
+	t.Run("cancelled", func(t *testing.T) {
+		clk = clock.Real
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+		start := time.Now()
+		if err := sleepContext(ctx, time.Hour); !errors.Is(err, context.Canceled) {
+			t.Errorf("sleepContext() error = %v, want context.Canceled", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("sleepContext() took %s after cancelling", elapsed)
+		}
+	})
+
+	t.Run("fake clock", func(t *testing.T) {
+		start := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+		fake := clock.NewFake(start)
+		clk = fake
+		if err := sleepContext(context.Background(), time.Hour); err != nil {
+			t.Errorf("sleepContext() error = %v", err)
+		}
+		if got := fake.Now(); !got.Equal(start.Add(time.Hour)) {
+			t.Errorf("fake clock is at %s, want an hour later", got)
+		}
+	})
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{failures: 1, want: time.Minute},
+		{failures: 2, want: 2 * time.Minute},
+		{failures: 4, want: 8 * time.Minute},
+		{failures: 10, want: maxDaemonBackoff},
+	}
+	for _, tt := range tests {
+		got := backoff(time.Minute, tt.failures)
+		min := time.Duration(float64(tt.want) * (1 - countdownJitter))
+		max := time.Duration(float64(tt.want) * (1 + countdownJitter))
+		if got < min || got > max {
+			t.Errorf("backoff(1m, %d) = %s, want %s give or take %0.0f%%", tt.failures, got, tt.want, countdownJitter*100)
+		}
+	}
+}
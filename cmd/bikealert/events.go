@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/themichaellai/bikealert/bikeshare"
+	"github.com/themichaellai/bikealert/geo"
+)
+
+// jsonLines is set by the --jsonl flag.
+var jsonLines bool
+
+// Event types written with --jsonl.
+const (
+	eventPoll   = "poll"
+	eventAlert  = "alert"
+	eventError  = "error"
+	eventDepart = "depart"
+	// eventRecover is written by the daemon when enough bikes are within
+	// limits again after an alert.
+	eventRecover = "recover"
+)
+
+// event is one line of --jsonl output. Fields are only ever added to
+// this, never renamed or removed, so scripts can rely on them.
+type event struct {
+	Time time.Time `json:"time"`
+	Type string    `json:"type"`
+	// RemainingSeconds is the time left until departure, on countdown
+	// events only.
+	RemainingSeconds *int64        `json:"remaining_seconds,omitempty"`
+	Bike             *eventVehicle `json:"bike,omitempty"`
+	// AcceptableBikes is how many bikes met the limits, on poll and
+	// alert events.
+	AcceptableBikes *int   `json:"acceptable_bikes,omitempty"`
+	Error           string `json:"error,omitempty"`
+}
+
+type eventVehicle struct {
+	ID        string  `json:"id"`
+	Name      string  `json:"name"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Address   string  `json:"address"`
+	// BatteryLevel is null if unknown.
+	BatteryLevel *int `json:"battery_level"`
+	// DistanceMiles and Direction are from the origin.
+	DistanceMiles float64 `json:"distance_miles"`
+	Direction     string  `json:"direction"`
+}
+
+func newEventVehicle(vehicle bikeshare.Vehicle, latitude, longitude float64) *eventVehicle {
+	return &eventVehicle{
+		ID:            vehicle.ID,
+		Name:          vehicle.Name,
+		Latitude:      vehicle.Latitude,
+		Longitude:     vehicle.Longitude,
+		Address:       vehicle.Address,
+		BatteryLevel:  batteryLevel(vehicle.BatteryLevel),
+		DistanceMiles: geo.Distance(latitude, longitude, vehicle.Latitude, vehicle.Longitude),
+		Direction:     geo.CompassPoint(geo.Bearing(latitude, longitude, vehicle.Latitude, vehicle.Longitude)),
+	}
+}
+
+// remainingSeconds converts the time left until departure for an event.
+func remainingSeconds(remaining time.Duration) *int64 {
+	seconds := int64(remaining.Seconds())
+	return &seconds
+}
+
+// emitEvent writes e to stdout as a single JSON line.
+func emitEvent(e event) {
+	e.Time = clk.Now()
+	json.NewEncoder(os.Stdout).Encode(e)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestEventJSON(t *testing.T) {
+	two := 2
+	tests := []struct {
+		name        string
+		e           event
+		wantKeys    []string
+		missingKeys []string
+	}{
+		{
+			name:        "countdown poll",
+			e:           event{Type: eventPoll, RemainingSeconds: remainingSeconds(90e9), AcceptableBikes: &two},
+			wantKeys:    []string{`"remaining_seconds":90`, `"acceptable_bikes":2`},
+			missingKeys: []string{`"bike"`, `"error"`},
+		},
+		{
+			name:     "countdown depart",
+			e:        event{Type: eventDepart, RemainingSeconds: remainingSeconds(0)},
+			wantKeys: []string{`"type":"depart"`, `"remaining_seconds":0`},
+		},
+		{
+			name:        "daemon poll",
+			e:           event{Type: eventPoll, AcceptableBikes: &two},
+			wantKeys:    []string{`"type":"poll"`, `"acceptable_bikes":2`},
+			missingKeys: []string{`"remaining_seconds"`},
+		},
+		{
+			name:        "daemon error",
+			e:           event{Type: eventError, Error: "down"},
+			wantKeys:    []string{`"error":"down"`},
+			missingKeys: []string{`"remaining_seconds"`, `"acceptable_bikes"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := json.Marshal(tt.e)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, key := range tt.wantKeys {
+				if !strings.Contains(string(b), key) {
+					t.Errorf("%s doesn't contain %s", b, key)
+				}
+			}
+			for _, key := range tt.missingKeys {
+				if strings.Contains(string(b), key) {
+					t.Errorf("%s contains %s", b, key)
+				}
+			}
+		})
+	}
+}
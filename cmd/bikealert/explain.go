@@ -0,0 +1,18 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// explain is set by the --explain flag.
+var explain bool
+
+// explainf prints a line to stderr about why results came out the way
+// they did, when --explain is set.
+func explainf(format string, args ...interface{}) {
+	if !explain {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "explain: "+format+"\n", args...)
+}
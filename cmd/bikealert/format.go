@@ -0,0 +1,278 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/themichaellai/bikealert/bikeshare"
+	"github.com/themichaellai/bikealert/geo"
+)
+
+// outputFormat is set by the --output flag. Empty means each command's
+// own default.
+var outputFormat string
+
+func setupOutputFormat() error {
+	switch outputFormat {
+	case "", "json", "csv", "table", "text":
+		return nil
+	}
+	return usageErrorf("unknown output format \"%s\"", outputFormat)
+}
+
+// vehicleResult is a vehicle in --output json.
+type vehicleResult struct {
+	Provider  string                `json:"provider"`
+	ID        string                `json:"id"`
+	Name      string                `json:"name"`
+	Type      bikeshare.VehicleType `json:"type"`
+	Latitude  float64               `json:"latitude"`
+	Longitude float64               `json:"longitude"`
+	Address   string                `json:"address"`
+	// BatteryLevel is null if unknown.
+	BatteryLevel *int    `json:"battery_level"`
+	RangeMiles   float64 `json:"range_miles"`
+	// Pricing is left out if the provider doesn't say.
+	Pricing *pricingResult `json:"pricing,omitempty"`
+	// DistanceMiles and Direction are from the origin.
+	DistanceMiles float64 `json:"distance_miles"`
+	Direction     string  `json:"direction"`
+	// Raw is every field the provider returned.
+	Raw interface{} `json:"raw,omitempty"`
+}
+
+// pricingResult is a vehicle's pricing in --output json.
+type pricingResult struct {
+	Currency  string  `json:"currency"`
+	UnlockFee float64 `json:"unlock_fee"`
+	PerMinute float64 `json:"per_minute"`
+}
+
+// stationResult is a station in --output json.
+type stationResult struct {
+	Provider          string      `json:"provider"`
+	ID                string      `json:"id"`
+	Name              string      `json:"name"`
+	Latitude          float64     `json:"latitude"`
+	Longitude         float64     `json:"longitude"`
+	Address           string      `json:"address"`
+	AvailableVehicles int         `json:"available_vehicles"`
+	AvailableDocks    int         `json:"available_docks"`
+	DistanceMiles     float64     `json:"distance_miles"`
+	Direction         string      `json:"direction"`
+	Raw               interface{} `json:"raw,omitempty"`
+}
+
+// printResults prints vehicles and hubs in the --output format, or def if
+// it wasn't given. A nil slice leaves that section out, while an empty one
+// prints it with no results.
+func printResults(def string, vehicles []bikeshare.Vehicle, hubs []bikeshare.Station, latitude, longitude float64) error {
+	format := outputFormat
+	if format == "" {
+		format = def
+	}
+	switch format {
+	case "json":
+		return printJSONResults(vehicles, hubs, latitude, longitude)
+	case "csv":
+		return printCSVResults(vehicles, hubs, latitude, longitude)
+	case "text":
+		printTextResults(vehicles, hubs, latitude, longitude)
+	default:
+		printTableResults(vehicles, hubs, latitude, longitude)
+	}
+	return nil
+}
+
+func printTableResults(vehicles []bikeshare.Vehicle, hubs []bikeshare.Station, latitude, longitude float64) {
+	if vehicles != nil {
+		fmt.Println("Bikes")
+		var bikeRows [][]cell
+		for _, vehicle := range vehicles {
+			bikeRows = append(bikeRows, []cell{
+				directionCell(latitude, longitude, vehicle.Latitude, vehicle.Longitude),
+				batteryCell(vehicle.BatteryLevel),
+				{text: vehicle.Name},
+				{text: formatPosition(vehicle.Address, vehicle.Latitude, vehicle.Longitude)},
+			})
+		}
+		printTable(bikeRows)
+	}
+	if hubs == nil {
+		return
+	}
+
+	if vehicles != nil {
+		fmt.Println("")
+	}
+	fmt.Println("Hubs")
+	var hubRows [][]cell
+	for _, hub := range hubs {
+		hubRows = append(hubRows, []cell{
+			directionCell(latitude, longitude, hub.Latitude, hub.Longitude),
+			{text: fmt.Sprintf("%d bikes", hub.AvailableVehicles)},
+			{text: hub.Name},
+			{text: formatPosition(hub.Address, hub.Latitude, hub.Longitude)},
+		})
+	}
+	printTable(hubRows)
+}
+
+// formatBattery formats a battery level as a percentage, or "?" if it is
+// unknown.
+func formatBattery(level int) string {
+	if level < 0 {
+		return "?"
+	}
+	return fmt.Sprintf("%d%%", level)
+}
+
+// batteryLevel returns a battery level for JSON output, or nil if it is
+// unknown, so it prints as null rather than -1.
+func batteryLevel(level int) *int {
+	if level < 0 {
+		return nil
+	}
+	return &level
+}
+
+// printTextResults prints one plain line per result, which is handy for
+// shell aliases.
+func printTextResults(vehicles []bikeshare.Vehicle, hubs []bikeshare.Station, latitude, longitude float64) {
+	for _, vehicle := range vehicles {
+		fmt.Printf("%0.2f miles %s, %s, %s\n",
+			geo.Distance(latitude, longitude, vehicle.Latitude, vehicle.Longitude),
+			geo.CompassPoint(geo.Bearing(latitude, longitude, vehicle.Latitude, vehicle.Longitude)),
+			formatBattery(vehicle.BatteryLevel),
+			formatPosition(vehicle.Address, vehicle.Latitude, vehicle.Longitude),
+		)
+	}
+	for _, hub := range hubs {
+		fmt.Printf("%0.2f miles %s, %d bikes, %s, %s\n",
+			geo.Distance(latitude, longitude, hub.Latitude, hub.Longitude),
+			geo.CompassPoint(geo.Bearing(latitude, longitude, hub.Latitude, hub.Longitude)),
+			hub.AvailableVehicles,
+			hub.Name,
+			formatPosition(hub.Address, hub.Latitude, hub.Longitude),
+		)
+	}
+}
+
+// printJSONResults prints {"bikes": [...], "hubs": [...]}, leaving out
+// either if nil.
+func printJSONResults(vehicles []bikeshare.Vehicle, hubs []bikeshare.Station, latitude, longitude float64) error {
+	// Pointers, so an empty list still prints as [] while a nil one is
+	// left out.
+	var result struct {
+		Bikes *[]vehicleResult `json:"bikes,omitempty"`
+		Hubs  *[]stationResult `json:"hubs,omitempty"`
+	}
+	if vehicles != nil {
+		bikes := newVehicleResults(vehicles, latitude, longitude)
+		result.Bikes = &bikes
+	}
+	if hubs != nil {
+		stations := newStationResults(hubs, latitude, longitude)
+		result.Hubs = &stations
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
+
+// newVehicleResults converts vehicles for JSON output, measuring from the
+// given origin. It never returns nil.
+func newVehicleResults(vehicles []bikeshare.Vehicle, latitude, longitude float64) []vehicleResult {
+	results := []vehicleResult{}
+	for _, vehicle := range vehicles {
+		results = append(results, vehicleResult{
+			Provider:      vehicle.Provider,
+			ID:            vehicle.ID,
+			Name:          vehicle.Name,
+			Type:          vehicle.Type,
+			Latitude:      vehicle.Latitude,
+			Longitude:     vehicle.Longitude,
+			Address:       vehicle.Address,
+			BatteryLevel:  batteryLevel(vehicle.BatteryLevel),
+			RangeMiles:    vehicle.RangeMiles,
+			Pricing:       newPricingResult(vehicle.Pricing),
+			DistanceMiles: geo.Distance(latitude, longitude, vehicle.Latitude, vehicle.Longitude),
+			Direction:     geo.CompassPoint(geo.Bearing(latitude, longitude, vehicle.Latitude, vehicle.Longitude)),
+			Raw:           vehicle.Extension,
+		})
+	}
+	return results
+}
+
+// newPricingResult converts pricing for JSON output, or returns nil if
+// there is none.
+func newPricingResult(pricing *bikeshare.Pricing) *pricingResult {
+	if pricing == nil {
+		return nil
+	}
+	return &pricingResult{Currency: pricing.Currency, UnlockFee: pricing.UnlockFee, PerMinute: pricing.PerMinute}
+}
+
+// newStationResults is like newVehicleResults for hubs.
+func newStationResults(hubs []bikeshare.Station, latitude, longitude float64) []stationResult {
+	results := []stationResult{}
+	for _, hub := range hubs {
+		results = append(results, stationResult{
+			Provider:          hub.Provider,
+			ID:                hub.ID,
+			Name:              hub.Name,
+			Latitude:          hub.Latitude,
+			Longitude:         hub.Longitude,
+			Address:           hub.Address,
+			AvailableVehicles: hub.AvailableVehicles,
+			AvailableDocks:    hub.AvailableDocks,
+			DistanceMiles:     geo.Distance(latitude, longitude, hub.Latitude, hub.Longitude),
+			Direction:         geo.CompassPoint(geo.Bearing(latitude, longitude, hub.Latitude, hub.Longitude)),
+			Raw:               hub.Extension,
+		})
+	}
+	return results
+}
+
+// printCSVResults prints bikes then hubs as CSV with a header row. The
+// kind column says which each row is, and columns that don't apply are
+// left empty.
+func printCSVResults(vehicles []bikeshare.Vehicle, hubs []bikeshare.Station, latitude, longitude float64) error {
+	w := csv.NewWriter(os.Stdout)
+	w.Write([]string{
+		"kind", "provider", "id", "name", "latitude", "longitude", "distance_miles", "direction",
+		"battery_level", "available_vehicles", "available_docks", "address",
+	})
+	formatFloat := func(f float64) string {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	for _, vehicle := range vehicles {
+		// An unknown battery level is left empty, like columns that
+		// don't apply.
+		var battery string
+		if vehicle.BatteryLevel >= 0 {
+			battery = strconv.Itoa(vehicle.BatteryLevel)
+		}
+		w.Write([]string{
+			"bike", vehicle.Provider, vehicle.ID, vehicle.Name,
+			formatFloat(vehicle.Latitude), formatFloat(vehicle.Longitude),
+			fmt.Sprintf("%0.3f", geo.Distance(latitude, longitude, vehicle.Latitude, vehicle.Longitude)),
+			geo.CompassPoint(geo.Bearing(latitude, longitude, vehicle.Latitude, vehicle.Longitude)),
+			battery, "", "", vehicle.Address,
+		})
+	}
+	for _, hub := range hubs {
+		w.Write([]string{
+			"hub", hub.Provider, hub.ID, hub.Name,
+			formatFloat(hub.Latitude), formatFloat(hub.Longitude),
+			fmt.Sprintf("%0.3f", geo.Distance(latitude, longitude, hub.Latitude, hub.Longitude)),
+			geo.CompassPoint(geo.Bearing(latitude, longitude, hub.Latitude, hub.Longitude)),
+			"", strconv.Itoa(hub.AvailableVehicles), strconv.Itoa(hub.AvailableDocks), hub.Address,
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
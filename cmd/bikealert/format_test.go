@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/themichaellai/bikealert/bikeshare"
+)
+
+// captureStdout returns what f writes to stdout.
+func captureStdout(t *testing.T, f func() error) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	done := make(chan string)
+	go func() {
+		b, _ := io.ReadAll(r)
+		done <- string(b)
+	}()
+	ferr := f()
+	w.Close()
+	out := <-done
+	if ferr != nil {
+		t.Fatal(ferr)
+	}
+	return out
+}
+
+func TestResultsBatteryLevel(t *testing.T) {
+	tests := []struct {
+		name     string
+		battery  int
+		wantJSON string
+		wantCSV  string
+	}{
+		{name: "known", battery: 80, wantJSON: "80", wantCSV: "80"},
+		{name: "empty", battery: 0, wantJSON: "0", wantCSV: "0"},
+		{name: "unknown", battery: -1, wantJSON: "null", wantCSV: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vehicles := []bikeshare.Vehicle{{ID: "a", BatteryLevel: tt.battery}}
+
+			out := captureStdout(t, func() error { return printJSONResults(vehicles, nil, 0, 0) })
+			var result struct {
+				Bikes []map[string]json.RawMessage `json:"bikes"`
+			}
+			if err := json.Unmarshal([]byte(out), &result); err != nil {
+				t.Fatal(err)
+			}
+			if got := string(result.Bikes[0]["battery_level"]); got != tt.wantJSON {
+				t.Errorf("JSON battery_level = %s, want %s", got, tt.wantJSON)
+			}
+
+			b, _ := json.Marshal(newEventVehicle(vehicles[0], 0, 0))
+			if want := `"battery_level":` + tt.wantJSON; !strings.Contains(string(b), want) {
+				t.Errorf("event bike %s doesn't contain %s", b, want)
+			}
+
+			out = captureStdout(t, func() error { return printCSVResults(vehicles, nil, 0, 0) })
+			rows, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, col := range rows[0] {
+				if col == "battery_level" && rows[1][i] != tt.wantCSV {
+					t.Errorf("CSV battery_level = %q, want %q", rows[1][i], tt.wantCSV)
+				}
+			}
+		})
+	}
+}
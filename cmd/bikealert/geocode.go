@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/themichaellai/bikealert/geocode"
+)
+
+// geocodeUserAgent identifies bikealert to Nominatim, as its usage policy
+// asks.
+const geocodeUserAgent = "bikealert (+https://github.com/themichaellai/bikealert)"
+
+// originAddress is set by --address.
+var originAddress string
+
+// newGeocoder returns a Nominatim geocoder for the server at $GEOCODE_URL,
+// OpenStreetMap's by default, with results cached at geocodeCachePath.
+func newGeocoder() (geocode.Geocoder, error) {
+	baseURL, set := os.LookupEnv("GEOCODE_URL")
+	if !set {
+		baseURL = geocode.DefaultNominatimURL
+	}
+	var geocoder geocode.Geocoder = geocode.NewNominatim(baseURL, geocodeUserAgent)
+	path, err := geocodeCachePath()
+	if err != nil || path == "" {
+		return geocoder, nil
+	}
+	return geocode.NewCache(geocoder, path)
+}
+
+// geocodeCachePath returns the file geocoding results are cached in.
+// $GEOCODE_CACHE overrides the default location in the user's cache
+// directory, and setting it empty turns caching off.
+func geocodeCachePath() (string, error) {
+	if path, set := os.LookupEnv("GEOCODE_CACHE"); set {
+		return path, nil
+	}
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "bikealert", "geocode.json"), nil
+}
+
+// geocodeOrigin resolves addr to coordinates.
+func geocodeOrigin(addr string) (float64, float64, error) {
+	geocoder, err := newGeocoder()
+	if err != nil {
+		return 0, 0, err
+	}
+	place, err := geocoder.Geocode(context.Background(), addr)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error looking up address: %w", err)
+	}
+	explainf("address %q resolved to %s (%f, %f)", addr, place.Name, place.Latitude, place.Longitude)
+	return place.Latitude, place.Longitude, nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// healthShutdownTimeout bounds how long the health server waits for
+// in-flight probes when the daemon stops.
+const healthShutdownTimeout = 5 * time.Second
+
+// healthProbes answers Kubernetes-style liveness and readiness probes for
+// the daemon.
+type healthProbes struct {
+	// maxAge is how old the last successful poll can be while ready.
+	maxAge time.Duration
+
+	mu         sync.Mutex
+	lastPoll   time.Time
+	isStopping bool
+}
+
+// polled records a successful poll.
+func (h *healthProbes) polled(at time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastPoll = at
+}
+
+// stopping makes readiness fail from now on, so no new traffic is routed
+// here while shutting down.
+func (h *healthProbes) stopping() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.isStopping = true
+}
+
+// ready reports whether the daemon has polled recently and isn't
+// shutting down, with a reason if not.
+func (h *healthProbes) ready(now time.Time) (bool, string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	switch {
+	case h.isStopping:
+		return false, "shutting down"
+	case h.lastPoll.IsZero():
+		return false, "no successful poll yet"
+	case now.Sub(h.lastPoll) > h.maxAge:
+		return false, fmt.Sprintf("last successful poll was %s ago", now.Sub(h.lastPoll).Round(time.Second))
+	}
+	return true, "ok"
+}
+
+// listen serves /livez and /readyz on addr in the background.
+func (h *healthProbes) listen(addr string) (*http.Server, error) {
+	mux := http.NewServeMux()
+	// Liveness only says the process is up to answer. Failing it for a
+	// provider outage would just get the pod restarted for nothing.
+	mux.HandleFunc("/livez", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		ok, reason := h.ready(clk.Now())
+		if !ok {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		fmt.Fprintln(w, reason)
+	})
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("error listening for health probes: %w", err)
+	}
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: healthShutdownTimeout}
+	go server.Serve(listener)
+	return server, nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/themichaellai/bikealert/bikeshare"
+	"github.com/themichaellai/bikealert/internal/conc"
+	"github.com/themichaellai/bikealert/store"
+)
+
+const (
+	defaultStoreRetention = 30 * 24 * time.Hour
+	// defaultStoreRadius is in miles.
+	defaultStoreRadius = 1.0
+)
+
+// openStore opens the history store in $STORE_DIR, or the config's
+// store_dir. It returns nil if neither is set.
+func openStore() (*store.Store, error) {
+	dir, set := os.LookupEnv("STORE_DIR")
+	if !set {
+		dir = cfg.storeDir
+	}
+	if dir == "" {
+		return nil, nil
+	}
+	st, err := store.Open(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error opening store: %w", err)
+	}
+	st.Warn = func(err error) {
+		fmt.Fprintf(os.Stderr, "warning reading history: %s\n", err.Error())
+	}
+	return st, nil
+}
+
+// storeRetention returns how long history is kept: $STORE_RETENTION, the
+// config's store_retention, or 30 days. 0 keeps it forever.
+func storeRetention() (time.Duration, error) {
+	def := defaultStoreRetention
+	if cfg.storeRetention != nil {
+		def = *cfg.storeRetention
+	}
+	return getEnvDuration("STORE_RETENTION", def)
+}
+
+// storeRadius returns how far from the origin, in miles, polls keep
+// vehicles and hubs: $STORE_RADIUS, the config's store_radius, or 1 mile.
+// 0 keeps the whole network.
+func storeRadius() (float64, error) {
+	def := defaultStoreRadius
+	if cfg.storeRadius != nil {
+		def = *cfg.storeRadius
+	}
+	return getEnvDistance("STORE_RADIUS", def)
+}
+
+// history records polls into the store.
+type history struct {
+	st        *store.Store
+	retention time.Duration
+	radius    float64
+}
+
+// openHistory returns where countdown and daemon polls are recorded, or
+// nil if there is no store.
+func openHistory() (*history, error) {
+	st, err := openStore()
+	if err != nil || st == nil {
+		return nil, err
+	}
+	retention, err := storeRetention()
+	if err != nil {
+		return nil, err
+	}
+	radius, err := storeRadius()
+	if err != nil {
+		return nil, err
+	}
+	return &history{st: st, retention: retention, radius: radius}, nil
+}
+
+// fetch fetches vehicles for a poll, along with the hubs to record if h
+// isn't nil, concurrently. Failing to fetch hubs is printed rather than
+// failing the poll, which only needs the vehicles.
+func (h *history) fetch(ctx context.Context, provider bikeshare.Provider,
+	latitude, longitude float64) ([]bikeshare.Vehicle, []bikeshare.Station, error) {
+	if h == nil {
+		vehicles, err := provider.NearbyVehicles(ctx, latitude, longitude)
+		return vehicles, nil, err
+	}
+	g, ctx := conc.WithContext(ctx, maxConcurrentFetches)
+	var vehicles []bikeshare.Vehicle
+	g.Go(func(ctx context.Context) error {
+		var err error
+		vehicles, err = provider.NearbyVehicles(ctx, latitude, longitude)
+		return err
+	})
+	var stations []bikeshare.Station
+	g.Go(func(ctx context.Context) error {
+		var err error
+		if stations, err = provider.NearbyStations(ctx, latitude, longitude); err != nil && ctx.Err() == nil {
+			fmt.Fprintf(os.Stderr, "error fetching hubs for history: %s\n", err.Error())
+		}
+		return nil
+	})
+	if err := g.Wait(); err != nil {
+		return nil, nil, err
+	}
+	return vehicles, stations, nil
+}
+
+// record saves a poll's vehicles and hubs near the origin, and prunes
+// history older than the retention. Failures are printed rather than
+// returned so they don't interrupt polling. It does nothing if h is nil.
+func (h *history) record(vehicles []bikeshare.Vehicle, stations []bikeshare.Station, latitude, longitude float64) {
+	if h == nil {
+		return
+	}
+	now := clk.Now()
+	snap := store.NewSnapshot(now, providerName(), latitude, longitude, h.radius, vehicles, stations)
+	if err := h.st.Add(snap); err != nil {
+		fmt.Fprintf(os.Stderr, "error saving history: %s\n", err.Error())
+		return
+	}
+	if h.retention <= 0 {
+		return
+	}
+	if pruned, err := h.st.Prune(now.Add(-h.retention)); err != nil {
+		fmt.Fprintf(os.Stderr, "error pruning history: %s\n", err.Error())
+	} else if pruned > 0 {
+		explainf("pruned %d days of history older than %s", pruned, h.retention)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/themichaellai/bikealert/bikeshare"
+)
+
+// ignoredBikesPath returns the file listing bike names to leave out of
+// results, one per line. $IGNORED_BIKES overrides the default location in
+// the user's config directory.
+func ignoredBikesPath() (string, error) {
+	if path, set := os.LookupEnv("IGNORED_BIKES"); set {
+		return path, nil
+	}
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "bikealert", "ignored-bikes"), nil
+}
+
+// loadIgnoredBikes returns the set of ignored bike names.
+func loadIgnoredBikes() (map[string]bool, error) {
+	path, err := ignoredBikesPath()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("error reading ignored bikes: %w", err)
+	}
+	defer f.Close()
+
+	ignored := map[string]bool{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if name := strings.TrimSpace(scanner.Text()); name != "" {
+			ignored[name] = true
+		}
+	}
+	return ignored, scanner.Err()
+}
+
+// ignoreBike adds a bike name to the ignore list.
+func ignoreBike(name string) error {
+	path, err := ignoredBikesPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(f, name); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// removeIgnored filters out vehicles whose names are ignored.
+func removeIgnored(vehicles []bikeshare.Vehicle, ignored map[string]bool) []bikeshare.Vehicle {
+	if len(ignored) == 0 {
+		return vehicles
+	}
+	result := vehicles[:0]
+	for _, vehicle := range vehicles {
+		if ignored[vehicle.Name] {
+			explainf("bike %s left out: on the ignore list", vehicle.Name)
+			continue
+		}
+		result = append(result, vehicle)
+	}
+	return result
+}
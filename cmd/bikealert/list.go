@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"strconv"
+
+	"github.com/themichaellai/bikealert/bikeshare"
+)
+
+// defaultLimit is how many bikes or hubs are listed unless --limit says
+// otherwise.
+const defaultLimit = 5
+
+var (
+	bikesFlags = newLocationFlagSet("bikes")
+	bikesLimit = limitFlag(bikesFlags, defaultLimit, "`number` of bikes to list")
+
+	hubsFlags = newLocationFlagSet("hubs")
+	hubsLimit = limitFlag(hubsFlags, defaultLimit, "`number` of hubs to list")
+)
+
+// limitValue is a --limit flag, which must be at least 1.
+type limitValue int
+
+func (v *limitValue) String() string {
+	return strconv.Itoa(int(*v))
+}
+
+func (v *limitValue) Set(s string) error {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return errors.New("parse error")
+	}
+	if n < 1 {
+		return errors.New("must be at least 1")
+	}
+	*v = limitValue(n)
+	return nil
+}
+
+// limitFlag adds --limit to flags, rejecting values below 1 as it parses.
+func limitFlag(flags *flag.FlagSet, def int, usage string) *int {
+	limit := def
+	flags.Var((*limitValue)(&limit), "limit", usage)
+	return &limit
+}
+
+// runBikes lists the closest bikes.
+func runBikes(args []string) error {
+	latitude, longitude, err := getOrigin()
+	if err != nil {
+		return err
+	}
+	ignoredBikes, err := loadIgnoredBikes()
+	if err != nil {
+		return err
+	}
+	provider, err := newProvider()
+	if err != nil {
+		return err
+	}
+	vehicles, err := fetchVehicles(provider, latitude, longitude)
+	if err != nil {
+		return err
+	}
+	vehicles = removeIgnored(vehicles, ignoredBikes)
+	sortVehicles(vehicles, latitude, longitude)
+
+	if err := printResults("table", firstVehicles(vehicles, *bikesLimit), nil, latitude, longitude); err != nil {
+		return err
+	}
+	logStats(provider)
+	return nil
+}
+
+// runHubs lists the closest hubs.
+func runHubs(args []string) error {
+	latitude, longitude, err := getOrigin()
+	if err != nil {
+		return err
+	}
+	hubOverrides, err := loadHubOverrides()
+	if err != nil {
+		return err
+	}
+	provider, err := newProvider()
+	if err != nil {
+		return err
+	}
+	hubs, err := fetchStations(provider, latitude, longitude)
+	if err != nil {
+		return err
+	}
+	hubs = applyHubOverrides(hubs, hubOverrides)
+	sortHubs(hubs, hubOverrides, latitude, longitude)
+
+	if err := printResults("table", nil, firstHubs(hubs, *hubsLimit), latitude, longitude); err != nil {
+		return err
+	}
+	logStats(provider)
+	return nil
+}
+
+// firstVehicles returns up to n vehicles, never nil.
+func firstVehicles(vehicles []bikeshare.Vehicle, n int) []bikeshare.Vehicle {
+	if len(vehicles) > n {
+		vehicles = vehicles[:n]
+	}
+	return append([]bikeshare.Vehicle{}, vehicles...)
+}
+
+// firstHubs returns up to n hubs, never nil.
+func firstHubs(hubs []bikeshare.Station, n int) []bikeshare.Station {
+	if len(hubs) > n {
+		hubs = hubs[:n]
+	}
+	return append([]bikeshare.Station{}, hubs...)
+}
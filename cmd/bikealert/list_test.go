@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"testing"
+)
+
+func TestLimitFlag(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    int
+		wantErr bool
+	}{
+		{args: nil, want: 5},
+		{args: []string{"--limit", "1"}, want: 1},
+		{args: []string{"--limit=20"}, want: 20},
+		{args: []string{"--limit", "0"}, wantErr: true},
+		{args: []string{"--limit", "-3"}, wantErr: true},
+		{args: []string{"--limit", "lots"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		flags := flag.NewFlagSet("test", flag.ContinueOnError)
+		flags.SetOutput(io.Discard)
+		limit := limitFlag(flags, 5, "")
+		err := flags.Parse(tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Parse(%q) error = %v, want error %t", tt.args, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && *limit != tt.want {
+			t.Errorf("Parse(%q) limit = %d, want %d", tt.args, *limit, tt.want)
+		}
+	}
+}
@@ -1,99 +1,356 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"math"
+	"math/rand"
 	"os"
 	"sort"
 	"strconv"
 	"time"
 
-	"github.com/pkg/errors"
+	"github.com/themichaellai/bikealert/bikeshare"
+	"github.com/themichaellai/bikealert/clock"
+	"github.com/themichaellai/bikealert/geo"
+	"github.com/themichaellai/bikealert/internal/conc"
+	"github.com/themichaellai/bikealert/internal/units"
 	"github.com/themichaellai/bikealert/jump"
 )
 
+var (
+	// seed is set by the --seed flag.
+	seed int64
+	// rng is the source for all of the CLI's random behavior.
+	rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+	// clk is the clock for all of the CLI's time-dependent behavior.
+	clk = clock.Real
+)
+
 func main() {
-	if err := run(); err != nil {
-		panic(err)
+	err := run()
+	var usage *usageError
+	switch {
+	case err == nil || errors.Is(err, flag.ErrHelp):
+	case errors.As(err, &usage):
+		if usage.msg != "" {
+			fmt.Fprintln(os.Stderr, usage.msg)
+		}
+		os.Exit(2)
+	default:
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
 	}
 }
 
 func run() error {
-	latitude, err := getEnvFloat("LAT")
+	noColor := flag.Bool("no-color", false, "disable colored output")
+	cpuProfile := flag.String("cpuprofile", "", "write a CPU profile to this file")
+	memProfile := flag.String("memprofile", "", "write a heap profile to this file on exit")
+	flag.StringVar(&configFlag, "config", "",
+		"config file (default $BIKEALERT_CONFIG or bikealert/config.yaml in the user config directory)")
+	flag.Int64Var(&seed, "seed", 0, "seed for random behavior, for reproducible runs (0 picks one at random)")
+	flag.BoolVar(&jsonLines, "jsonl", false, "in countdown and daemon mode, write each event as a JSON line")
+	flag.BoolVar(&explain, "explain", false, "explain to stderr how results were filtered and ranked")
+	flag.StringVar(&positionFormat, "position", "address",
+		"how to show positions: address, pluscode or w3w (needs $W3W_API_KEY)")
+	flag.StringVar(&outputFormat, "output", "",
+		"output format for the default command, nearest, bikes and hubs: json, csv, table or text")
+	addLocationFlags(flag.CommandLine)
+	addProviderFlags(flag.CommandLine)
+	flag.StringVar(&rankProfileName, "profile", "distance",
+		"how to rank bikes: distance, day or night (see $RANK_SCHEDULE)")
+	flag.Parse()
+	var err error
+	if cfg, err = loadConfig(); err != nil {
+		return err
+	}
+	_, noColorEnv := os.LookupEnv("NO_COLOR")
+	useColor = !*noColor && !noColorEnv && stdoutIsTerminal()
+	if err := setupPositionFormat(); err != nil {
+		return err
+	}
+	if err := setupRankProfile(); err != nil {
+		return err
+	}
+	if err := setupOutputFormat(); err != nil {
+		return err
+	}
+	if seed != 0 {
+		rng = rand.New(rand.NewSource(seed))
+	}
+
+	if *cpuProfile != "" {
+		stop, err := startCPUProfile(*cpuProfile)
+		if err != nil {
+			return err
+		}
+		defer stop()
+	}
+	if *memProfile != "" {
+		defer writeMemProfile(*memProfile)
+	}
+
+	if flag.NArg() > 0 {
+		return runCommand(flag.Args())
+	}
+	return runDefault()
+}
+
+// runDefault prints the closest bikes and hubs.
+func runDefault() error {
+	latitude, longitude, err := getOrigin()
+	if err != nil {
+		return err
+	}
+	hubOverrides, err := loadHubOverrides()
 	if err != nil {
 		return err
 	}
-	longitude, err := getEnvFloat("LNG")
+	ignoredBikes, err := loadIgnoredBikes()
 	if err != nil {
 		return err
 	}
 
-	jumpClient := jump.NewClient(jump.NetworkSanFrancisco)
+	provider, err := newProvider()
+	if err != nil {
+		return err
+	}
+	vehicles, hubs, err := fetchAll(provider, latitude, longitude)
+	if err != nil {
+		return err
+	}
+	vehicles = removeIgnored(vehicles, ignoredBikes)
+	sortVehicles(vehicles, latitude, longitude)
+	hubs = applyHubOverrides(hubs, hubOverrides)
+	sortHubs(hubs, hubOverrides, latitude, longitude)
 
-	var bikes []jump.Bike
-	var bikesErr error
-	bikesDone := doAsync(func() {
-		bikes, bikesErr = jumpClient.Bikes()
-	})
+	err = printResults("table", firstVehicles(vehicles, defaultLimit), firstHubs(hubs, defaultLimit), latitude, longitude)
+	if err != nil {
+		return err
+	}
+	// Transit departures only fit the human-readable formats.
+	if feedPath, set := os.LookupEnv("GTFS_FEED"); set && (outputFormat == "" || outputFormat == "table") {
+		fmt.Println("")
+		if err := printTransit(feedPath, latitude, longitude); err != nil {
+			return err
+		}
+	}
+	logStats(provider)
+	return nil
+}
 
-	var hubs []jump.Hub
-	var hubsErr error
-	hubsDone := doAsync(func() {
-		hubs, hubsErr = jumpClient.Hubs()
-	})
+// runNearest prints the closest bike, on a single line unless --output
+// says otherwise.
+func runNearest(args []string) error {
+	latitude, longitude, err := getOrigin()
+	if err != nil {
+		return err
+	}
 
-	select {
-	case <-bikesDone:
-	case <-time.After(5 * time.Second):
-		return fmt.Errorf("timed out waiting for bikes response")
+	ignoredBikes, err := loadIgnoredBikes()
+	if err != nil {
+		return err
+	}
+
+	provider, err := newProvider()
+	if err != nil {
+		return err
+	}
+	vehicles, err := fetchVehicles(provider, latitude, longitude)
+	if err != nil {
+		return err
 	}
-	if bikesErr != nil {
-		return bikesErr
+	vehicles = removeIgnored(vehicles, ignoredBikes)
+	if len(vehicles) == 0 {
+		return fmt.Errorf("no bikes found")
 	}
-	sort.Slice(bikes, func(i, j int) bool {
-		iLocation := bikes[i].CurrentPosition.Coordinates
-		jLocation := bikes[j].CurrentPosition.Coordinates
-		iDistance := distance(latitude, longitude, iLocation[1], iLocation[0])
-		jDistance := distance(latitude, longitude, jLocation[1], jLocation[0])
-		return iDistance < jDistance
+
+	sortVehicles(vehicles, latitude, longitude)
+	return printResults("text", vehicles[:1], nil, latitude, longitude)
+}
+
+// maxConcurrentFetches limits how many requests one command makes to the
+// provider at once.
+const maxConcurrentFetches = 2
+
+// fetchVehicles retrieves bikes around the given coordinates. The
+// provider's timeout bounds how long this takes.
+func fetchVehicles(provider bikeshare.Provider, latitude, longitude float64) ([]bikeshare.Vehicle, error) {
+	return provider.NearbyVehicles(context.Background(), latitude, longitude)
+}
+
+// fetchStations retrieves hubs around the given coordinates.
+func fetchStations(provider bikeshare.Provider, latitude, longitude float64) ([]bikeshare.Station, error) {
+	return provider.NearbyStations(context.Background(), latitude, longitude)
+}
+
+// fetchAll retrieves bikes and hubs around the given coordinates
+// concurrently.
+func fetchAll(provider bikeshare.Provider, latitude, longitude float64) ([]bikeshare.Vehicle, []bikeshare.Station, error) {
+	g, _ := conc.WithContext(context.Background(), maxConcurrentFetches)
+	var vehicles []bikeshare.Vehicle
+	g.Go(func(ctx context.Context) error {
+		var err error
+		vehicles, err = provider.NearbyVehicles(ctx, latitude, longitude)
+		return err
 	})
+	var hubs []bikeshare.Station
+	g.Go(func(ctx context.Context) error {
+		var err error
+		hubs, err = provider.NearbyStations(ctx, latitude, longitude)
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		return nil, nil, err
+	}
+	return vehicles, hubs, nil
+}
 
-	fmt.Println("Bikes")
-	for _, bike := range bikes[:5] {
-		location := bike.CurrentPosition.Coordinates
-		dist := distance(latitude, longitude, location[1], location[0])
-		fmt.Printf("Bike %s %s (%0.2f miles, %d%%)\n",
-			bike.Name,
-			bike.Address,
-			dist,
-			bike.EbikeBatteryLevel,
-		)
+// logStats prints the provider's request counters to stderr when $VERBOSE
+// is set, if it keeps any.
+func logStats(provider bikeshare.Provider) {
+	if p, ok := providerStats(provider); ok {
+		logStatsDelta(jump.Stats{}, p.Stats())
 	}
-	fmt.Println("")
+}
 
-	sort.Slice(hubs, func(i, j int) bool {
-		iLocation := hubs[i].MiddlePoint.Coordinates
-		jLocation := hubs[j].MiddlePoint.Coordinates
-		iDistance := distance(latitude, longitude, iLocation[1], iLocation[0])
-		jDistance := distance(latitude, longitude, jLocation[1], jLocation[0])
-		return iDistance < jDistance
-	})
+// logStatsDelta prints the counters accumulated between two snapshots to
+// stderr when $VERBOSE is set.
+func logStatsDelta(before, after jump.Stats) {
+	if _, set := os.LookupEnv("VERBOSE"); !set {
+		return
+	}
+	stats := statsDelta(before, after)
+	fmt.Fprintf(os.Stderr, "%d requests, %d bytes, %d reused connections, %d over HTTP/2\n",
+		stats.Requests, stats.BytesRead, stats.ReusedConns, stats.HTTP2Responses)
+}
+
+// originLatitude and originLongitude are set by --lat and --lng, and
+// originName by --location.
+var originLatitude, originLongitude, originName string
 
-	select {
-	case <-hubsDone:
-	case <-time.After(5 * time.Second):
-		return fmt.Errorf("timed out waiting for hubs response")
+// getOrigin returns the latitude and longitude to search around. In order,
+// it takes them from --lat and --lng, --address, the config location named
+// by --location or $LOCATION, $LAT and $LNG, or the config's default
+// location.
+func getOrigin() (float64, float64, error) {
+	if originLatitude == "" && originLongitude == "" {
+		if originAddress != "" {
+			return geocodeOrigin(originAddress)
+		}
+		if name := getOriginName(); name != "" {
+			return cfg.lookupLocation(name)
+		}
 	}
-	if hubsErr != nil {
-		return hubsErr
+	latitude, err := getFlagOrEnvFloat(originLatitude, "lat", "LAT")
+	if err != nil {
+		return 0, 0, err
 	}
-	fmt.Println("Hubs")
-	for _, hub := range hubs[:5] {
-		location := hub.MiddlePoint.Coordinates
-		dist := distance(latitude, longitude, location[1], location[0])
-		fmt.Printf("Hub %s %s (%d bikes) (%0.2f miles)\n", hub.Name, hub.Address, hub.AvailableBikes+hub.AvailableEbikes, dist)
+	longitude, err := getFlagOrEnvFloat(originLongitude, "lng", "LNG")
+	if err != nil {
+		return 0, 0, err
+	}
+	return latitude, longitude, nil
+}
+
+// getOriginName returns the name of the config location getOrigin should
+// use, or "" to use coordinates.
+func getOriginName() string {
+	if originName != "" {
+		return originName
+	}
+	if name, set := os.LookupEnv("LOCATION"); set {
+		return name
+	}
+	_, latSet := os.LookupEnv("LAT")
+	_, lngSet := os.LookupEnv("LNG")
+	if latSet || lngSet {
+		return ""
+	}
+	return cfg.location
+}
+
+// addLocationFlags adds --lat, --lng, --address and --location to flags,
+// so they can be given before or after a subcommand.
+func addLocationFlags(flags *flag.FlagSet) {
+	flags.StringVar(&originLatitude, "lat", originLatitude, "latitude to search around (default $LAT)")
+	flags.StringVar(&originLongitude, "lng", originLongitude, "longitude to search around (default $LNG)")
+	flags.StringVar(&originAddress, "address", originAddress,
+		"address to search around, looked up with OpenStreetMap's Nominatim (see $GEOCODE_URL)")
+	flags.StringVar(&originName, "location", originName,
+		"named location from the config file to search around (default $LOCATION)")
+}
+
+// addProviderFlags adds --provider and --network to flags.
+func addProviderFlags(flags *flag.FlagSet) {
+	flags.StringVar(&providerFlag, "provider", providerFlag, "bikeshare provider: jump or gbfs (default $PROVIDER)")
+	flags.StringVar(&networkFlag, "network", networkFlag,
+		"provider network, e.g. a JUMP network ID (default $JUMP_NETWORK or San Francisco)")
+}
+
+// getFlagOrEnvFloat parses val, the value of --name, falling back to $env
+// if the flag wasn't given.
+func getFlagOrEnvFloat(val, name, env string) (float64, error) {
+	if val == "" {
+		if _, set := os.LookupEnv(env); !set {
+			return 0, fmt.Errorf("neither --%s nor envvar \"%s\" set", name, env)
+		}
+		return getEnvFloat(env)
+	}
+	f, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return f, fmt.Errorf("error parsing --%s as float: %w", name, err)
+	}
+	return f, nil
+}
+
+// sortVehicles sorts vehicles for the given coordinates, best first, using
+// the current ranking profile.
+func sortVehicles(vehicles []bikeshare.Vehicle, latitude, longitude float64) {
+	profile := currentRankProfile()
+	sort.Slice(vehicles, func(i, j int) bool {
+		return profile.score(vehicles[i], latitude, longitude) < profile.score(vehicles[j], latitude, longitude)
+	})
+	if profile.batteryWeight == 0 {
+		explainf("ranked %d bikes with the %s profile: straight-line distance only; battery does not affect rank",
+			len(vehicles), profile.name)
+	} else {
+		explainf("ranked %d bikes with the %s profile: an empty battery counts as %0.0f%% further away",
+			len(vehicles), profile.name, profile.batteryWeight*100)
+	}
+	for i, vehicle := range vehicles {
+		if i == 5 {
+			break
+		}
+		explainf("  #%d bike %s: %0.3f mi, score %0.3f", i+1, vehicle.Name,
+			geo.Distance(latitude, longitude, vehicle.Latitude, vehicle.Longitude),
+			profile.score(vehicle, latitude, longitude))
 	}
-	return nil
+}
+
+// sortHubs sorts hubs by distance from the given coordinates, closest
+// first, under the current ranking profile. overrides say which hubs are
+// lit.
+func sortHubs(hubs []bikeshare.Station, overrides map[string]hubOverride, latitude, longitude float64) {
+	profile := currentRankProfile()
+	sort.Slice(hubs, func(i, j int) bool {
+		return profile.hubScore(hubs[i], overrides[hubs[i].ID].Lit, latitude, longitude) <
+			profile.hubScore(hubs[j], overrides[hubs[j].ID].Lit, latitude, longitude)
+	})
+	if profile.unlitWeight == 0 {
+		explainf("ranked %d hubs by straight-line distance only; available bikes do not affect rank", len(hubs))
+	} else {
+		explainf("ranked %d hubs with the %s profile: a hub not marked lit counts as %0.0f%% further away",
+			len(hubs), profile.name, profile.unlitWeight*100)
+	}
+}
+
+// hubDistance returns the distance in miles from the given coordinates to
+// a hub.
+func hubDistance(hub bikeshare.Station, latitude, longitude float64) float64 {
+	return geo.Distance(latitude, longitude, hub.Latitude, hub.Longitude)
 }
 
 func getEnvFloat(name string) (float64, error) {
@@ -103,37 +360,62 @@ func getEnvFloat(name string) (float64, error) {
 	}
 	f, err := strconv.ParseFloat(val, 64)
 	if err != nil {
-		return f, errors.Wrap(err, "error parsing env var \"%s\" as float")
+		return f, fmt.Errorf("error parsing env var \"%s\" as float: %w", name, err)
 	}
 	return f, nil
 }
 
-func hsin(theta float64) float64 {
-	return math.Pow(math.Sin(theta/2), 2)
+// getEnvInt parses $name as an integer, returning def if it is not set.
+func getEnvInt(name string, def int) (int, error) {
+	val, set := os.LookupEnv(name)
+	if !set {
+		return def, nil
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil {
+		return 0, fmt.Errorf("error parsing env var \"%s\" as integer: %w", name, err)
+	}
+	return n, nil
 }
 
-// distance returns distance between two coordinates in miles.
-func distance(lat1, lon1, lat2, lon2 float64) float64 {
-	var la1, lo1, la2, lo2, r float64
-	// convert to radians
-	// must cast radius as float to multiply later
-	la1 = lat1 * math.Pi / 180
-	lo1 = lon1 * math.Pi / 180
-	la2 = lat2 * math.Pi / 180
-	lo2 = lon2 * math.Pi / 180
-	r = 3958.756
-
-	// calculate
-	h := hsin(la2-la1) + math.Cos(la1)*math.Cos(la2)*hsin(lo2-lo1)
+// getEnvDistance parses $name as a distance in miles, such as "0.5mi" or
+// "800m", returning def if it is not set.
+func getEnvDistance(name string, def float64) (float64, error) {
+	val, set := os.LookupEnv(name)
+	if !set {
+		return def, nil
+	}
+	d, err := units.ParseDistance(val)
+	if err != nil {
+		return 0, fmt.Errorf("error parsing env var \"%s\": %w", name, err)
+	}
+	return d, nil
+}
 
-	return 2 * r * math.Asin(math.Sqrt(h))
+// getEnvPercent parses $name as a percentage, such as "60%", returning def
+// if it is not set.
+func getEnvPercent(name string, def float64) (float64, error) {
+	val, set := os.LookupEnv(name)
+	if !set {
+		return def, nil
+	}
+	p, err := units.ParsePercent(val)
+	if err != nil {
+		return 0, fmt.Errorf("error parsing env var \"%s\": %w", name, err)
+	}
+	return p, nil
 }
 
-func doAsync(f func()) <-chan struct{} {
-	ch := make(chan struct{})
-	go func() {
-		defer close(ch)
-		f()
-	}()
-	return ch
+// getEnvDuration parses $name as a duration, such as "45s" or "10m",
+// returning def if it is not set.
+func getEnvDuration(name string, def time.Duration) (time.Duration, error) {
+	val, set := os.LookupEnv(name)
+	if !set {
+		return def, nil
+	}
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, fmt.Errorf("error parsing env var \"%s\" as duration: %w", name, err)
+	}
+	return d, nil
 }
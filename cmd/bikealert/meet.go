@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/themichaellai/bikealert/geo"
+)
+
+// meetingSpot is a bike or hub that two people can both walk to.
+type meetingSpot struct {
+	latitude  float64
+	longitude float64
+	// walk is the longer of the two walks, in miles.
+	walk float64
+	row  []cell
+}
+
+// runMeet finds the bikes and hubs that minimize the longer of two
+// people's walks, for meeting up to ride together.
+func runMeet(args []string) error {
+	if len(args) != 2 {
+		return usageErrorf("usage: bikealert meet name=lat,lng name=lat,lng")
+	}
+	people, err := parseNamedPoints(args)
+	if err != nil {
+		return err
+	}
+	hubOverrides, err := loadHubOverrides()
+	if err != nil {
+		return err
+	}
+	ignoredBikes, err := loadIgnoredBikes()
+	if err != nil {
+		return err
+	}
+
+	provider, err := newProvider()
+	if err != nil {
+		return err
+	}
+	// Search around the midpoint, which is close enough at city scale.
+	vehicles, hubs, err := fetchAll(provider,
+		(people[0].latitude+people[1].latitude)/2, (people[0].longitude+people[1].longitude)/2)
+	if err != nil {
+		return err
+	}
+	vehicles = removeIgnored(vehicles, ignoredBikes)
+	hubs = applyHubOverrides(hubs, hubOverrides)
+
+	walk := func(latitude, longitude float64) float64 {
+		return math.Max(
+			geo.Distance(people[0].latitude, people[0].longitude, latitude, longitude),
+			geo.Distance(people[1].latitude, people[1].longitude, latitude, longitude),
+		)
+	}
+	walkCells := func(latitude, longitude float64) []cell {
+		var cells []cell
+		for _, person := range people {
+			cells = append(cells, cell{text: person.name + " " + directionCell(
+				person.latitude, person.longitude, latitude, longitude).text})
+		}
+		return cells
+	}
+
+	var bikeSpots []meetingSpot
+	for _, vehicle := range vehicles {
+		bikeSpots = append(bikeSpots, meetingSpot{
+			walk: walk(vehicle.Latitude, vehicle.Longitude),
+			row: append(walkCells(vehicle.Latitude, vehicle.Longitude),
+				batteryCell(vehicle.BatteryLevel),
+				cell{text: vehicle.Name},
+				cell{text: formatPosition(vehicle.Address, vehicle.Latitude, vehicle.Longitude)},
+			),
+		})
+	}
+
+	// Riding together needs a bike each, so only hubs with two or more
+	// count.
+	var hubSpots []meetingSpot
+	for _, hub := range hubs {
+		if hub.AvailableVehicles < 2 {
+			continue
+		}
+		hubSpots = append(hubSpots, meetingSpot{
+			walk: walk(hub.Latitude, hub.Longitude),
+			row: append(walkCells(hub.Latitude, hub.Longitude),
+				cell{text: fmt.Sprintf("%d bikes", hub.AvailableVehicles)},
+				cell{text: hub.Name},
+				cell{text: formatPosition(hub.Address, hub.Latitude, hub.Longitude)},
+			),
+		})
+	}
+
+	fmt.Println("Bikes")
+	printSpots(bikeSpots)
+	fmt.Println("")
+	fmt.Println("Hubs with 2+ bikes")
+	printSpots(hubSpots)
+	return nil
+}
+
+// printSpots prints the five spots with the shortest longer walk.
+func printSpots(spots []meetingSpot) {
+	sort.Slice(spots, func(i, j int) bool {
+		return spots[i].walk < spots[j].walk
+	})
+	if len(spots) > 5 {
+		spots = spots[:5]
+	}
+	rows := make([][]cell, len(spots))
+	for i, spot := range spots {
+		rows[i] = spot.row
+	}
+	printTable(rows)
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/themichaellai/bikealert/geo"
+	"github.com/themichaellai/bikealert/notify"
+)
+
+// sampleRemaining is the time left shown in a test countdown alert when
+// $DEPART isn't set.
+const sampleRemaining = 10 * time.Minute
+
+var (
+	notifyFlags   = newLocationFlagSet("notify")
+	notifyChannel = notifyFlags.String("channel", "", "only send to notifiers of this type, e.g. slack")
+	notifyAlert   = notifyFlags.String("alert", "countdown", "which alert to send: countdown or daemon")
+)
+
+// loadNotifier reads the JSON file named by $NOTIFY_CONFIG, a list of
+// notifiers to send alerts to:
+//
+//	[{"type": "slack", "url": "https://hooks.slack.com/services/..."}]
+//
+// See notify.Config for every type. Without the env var it uses the config
+// file's notify list, returning nil if that is empty too.
+func loadNotifier() (notify.Notifier, error) {
+	cfgs, err := loadNotifierConfigs()
+	if err != nil || len(cfgs) == 0 {
+		return nil, err
+	}
+	return notify.NewMulti(cfgs)
+}
+
+// loadNotifierConfigs returns the notifiers loadNotifier would send to,
+// without creating them.
+func loadNotifierConfigs() ([]notify.Config, error) {
+	path, set := os.LookupEnv("NOTIFY_CONFIG")
+	if !set {
+		return withClock(cfg.notify), nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading notifier config: %w", err)
+	}
+	defer f.Close()
+
+	var cfgs []notify.Config
+	if err := json.NewDecoder(f).Decode(&cfgs); err != nil {
+		return nil, fmt.Errorf("error parsing notifier config %s: %w", path, err)
+	}
+	return withClock(cfgs), nil
+}
+
+// withClock returns cfgs set to date messages by clk.
+func withClock(cfgs []notify.Config) []notify.Config {
+	result := make([]notify.Config, len(cfgs))
+	for i, c := range cfgs {
+		c.Clock = clk
+		result[i] = c
+	}
+	return result
+}
+
+// sendNotification sends msg if a notifier is configured. Failures are
+// printed rather than returned so one bad destination doesn't stop a
+// countdown.
+func sendNotification(notifier notify.Notifier, msg notify.Message) {
+	if notifier == nil {
+		return
+	}
+	if err := notifier.Notify(context.Background(), msg); err != nil {
+		fmt.Fprintf(os.Stderr, "error sending notification: %s\n", err.Error())
+	}
+}
+
+// runNotify handles "notify test", which sends a sample alert built from
+// the bikes around the origin right now through each configured notifier,
+// so credentials and wording can be checked before a real alert is due.
+func runNotify(args []string) error {
+	if len(args) == 0 || args[0] != "test" {
+		return usageErrorf("usage: bikealert notify test [--channel type] [--alert countdown|daemon]")
+	}
+	// Flags may also follow "test".
+	if err := parseCommandFlags(notifyFlags, args[1:]); err != nil {
+		return err
+	}
+
+	cfgs, err := loadNotifierConfigs()
+	if err != nil {
+		return err
+	}
+	if *notifyChannel != "" {
+		var matching []notify.Config
+		for _, c := range cfgs {
+			if c.Type == *notifyChannel {
+				matching = append(matching, c)
+			}
+		}
+		if len(matching) == 0 {
+			return fmt.Errorf("no %s notifier in NOTIFY_CONFIG or the config file", *notifyChannel)
+		}
+		cfgs = matching
+	}
+	if len(cfgs) == 0 {
+		return fmt.Errorf("no notifiers set; add some to NOTIFY_CONFIG or run bikealert alert new --interactive")
+	}
+	// Check every notifier before sending to any.
+	notifiers := make([]notify.Notifier, len(cfgs))
+	for i, c := range cfgs {
+		if notifiers[i], err = notify.New(c); err != nil {
+			return err
+		}
+	}
+
+	msg, err := sampleMessage(*notifyAlert)
+	if err != nil {
+		return err
+	}
+	msg.Title = "Test: " + msg.Title
+	fmt.Printf("%s\n%s\n\n", msg.Title, msg.Body)
+
+	failed := 0
+	for i, n := range notifiers {
+		if err := n.Notify(context.Background(), msg); err != nil {
+			fmt.Printf("%s: failed: %s\n", cfgs[i].Type, err.Error())
+			failed++
+			continue
+		}
+		fmt.Printf("%s: sent\n", cfgs[i].Type)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d notifiers failed", failed, len(notifiers))
+	}
+	return nil
+}
+
+// sampleMessage renders the countdown or daemon alert from current bikes
+// and the alert limits, as if they had just run low.
+func sampleMessage(alert string) (notify.Message, error) {
+	if alert != "countdown" && alert != "daemon" {
+		return notify.Message{}, fmt.Errorf("unknown alert \"%s\"; try countdown or daemon", alert)
+	}
+	latitude, longitude, err := getOrigin()
+	if err != nil {
+		return notify.Message{}, err
+	}
+	limits, err := loadAlertLimits()
+	if err != nil {
+		return notify.Message{}, err
+	}
+	ignoredBikes, err := loadIgnoredBikes()
+	if err != nil {
+		return notify.Message{}, err
+	}
+	provider, err := newProvider()
+	if err != nil {
+		return notify.Message{}, err
+	}
+	vehicles, err := fetchVehicles(provider, latitude, longitude)
+	if err != nil {
+		return notify.Message{}, err
+	}
+	vehicles = removeIgnored(vehicles, ignoredBikes)
+	acceptableBikes := limits.countAcceptable(vehicles, latitude, longitude)
+
+	if alert == "daemon" {
+		return daemonMessage(acceptableBikes, limits.groupSize, false), nil
+	}
+	if len(vehicles) == 0 {
+		return notify.Message{}, fmt.Errorf("no bikes around the origin to build a countdown alert from; try --alert daemon")
+	}
+	remaining := sampleRemaining
+	if _, set := os.LookupEnv("DEPART"); set {
+		departure, err := getDeparture()
+		if err != nil {
+			return notify.Message{}, err
+		}
+		remaining = departure.Sub(clk.Now())
+	}
+	sortVehicles(vehicles, latitude, longitude)
+	best := vehicles[0]
+	dist := geo.Distance(latitude, longitude, best.Latitude, best.Longitude)
+	return alertMessage(best, dist, acceptableBikes, limits.groupSize, remaining), nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/themichaellai/bikealert/bikeshare"
+	"github.com/themichaellai/bikealert/geo"
+)
+
+// namedPoint is a coordinate given on the command line as
+// "name=lat,lng".
+type namedPoint struct {
+	name      string
+	latitude  float64
+	longitude float64
+}
+
+func parseNamedPoint(s string) (namedPoint, error) {
+	name, coords, ok := strings.Cut(s, "=")
+	if !ok {
+		return namedPoint{}, fmt.Errorf("expected name=lat,lng, got \"%s\"", s)
+	}
+	latStr, lngStr, ok := strings.Cut(coords, ",")
+	if !ok {
+		return namedPoint{}, fmt.Errorf("expected name=lat,lng, got \"%s\"", s)
+	}
+	latitude, err := strconv.ParseFloat(strings.TrimSpace(latStr), 64)
+	if err != nil {
+		return namedPoint{}, fmt.Errorf("error parsing latitude in \"%s\": %w", s, err)
+	}
+	longitude, err := strconv.ParseFloat(strings.TrimSpace(lngStr), 64)
+	if err != nil {
+		return namedPoint{}, fmt.Errorf("error parsing longitude in \"%s\": %w", s, err)
+	}
+	return namedPoint{name: name, latitude: latitude, longitude: longitude}, nil
+}
+
+func parseNamedPoints(args []string) ([]namedPoint, error) {
+	points := make([]namedPoint, len(args))
+	for i, arg := range args {
+		point, err := parseNamedPoint(arg)
+		if err != nil {
+			return nil, err
+		}
+		points[i] = point
+	}
+	return points, nil
+}
+
+// nearestVehicle returns the vehicle closest to a point and its distance.
+func nearestVehicle(vehicles []bikeshare.Vehicle, latitude, longitude float64) (bikeshare.Vehicle, float64) {
+	var best bikeshare.Vehicle
+	bestDistance := math.Inf(1)
+	for _, vehicle := range vehicles {
+		if dist := geo.Distance(latitude, longitude, vehicle.Latitude, vehicle.Longitude); dist < bestDistance {
+			best, bestDistance = vehicle, dist
+		}
+	}
+	return best, bestDistance
+}
+
+// runOrigins shows the closest bike to each of several starting points,
+// such as building exits, and recommends the one with the shortest walk.
+func runOrigins(args []string) error {
+	if len(args) == 0 {
+		return usageErrorf("usage: bikealert origins name=lat,lng [name=lat,lng ...]")
+	}
+	origins, err := parseNamedPoints(args)
+	if err != nil {
+		return err
+	}
+	ignoredBikes, err := loadIgnoredBikes()
+	if err != nil {
+		return err
+	}
+
+	provider, err := newProvider()
+	if err != nil {
+		return err
+	}
+	vehicles, err := fetchVehicles(provider, origins[0].latitude, origins[0].longitude)
+	if err != nil {
+		return err
+	}
+	vehicles = removeIgnored(vehicles, ignoredBikes)
+	if len(vehicles) == 0 {
+		return fmt.Errorf("no bikes found")
+	}
+
+	var rows [][]cell
+	bestOrigin := -1
+	bestDistance := math.Inf(1)
+	var bestVehicle bikeshare.Vehicle
+	for i, origin := range origins {
+		vehicle, dist := nearestVehicle(vehicles, origin.latitude, origin.longitude)
+		rows = append(rows, []cell{
+			{text: origin.name},
+			directionCell(origin.latitude, origin.longitude, vehicle.Latitude, vehicle.Longitude),
+			batteryCell(vehicle.BatteryLevel),
+			{text: vehicle.Name},
+			{text: formatPosition(vehicle.Address, vehicle.Latitude, vehicle.Longitude)},
+		})
+		if dist < bestDistance {
+			bestOrigin, bestDistance, bestVehicle = i, dist, vehicle
+		}
+	}
+	printTable(rows)
+	fmt.Printf("\nLeave from %s: %0.2f miles to bike %s.\n",
+		origins[bestOrigin].name, bestDistance, bestVehicle.Name)
+	return nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/themichaellai/bikealert/geo"
+)
+
+// ANSI color codes.
+const (
+	colorRed    = "31"
+	colorGreen  = "32"
+	colorYellow = "33"
+	colorDim    = "2"
+)
+
+// useColor is false when --no-color or $NO_COLOR is set, or stdout isn't
+// a terminal.
+var useColor = true
+
+func stdoutIsTerminal() bool {
+	fi, err := os.Stdout.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// cell is one table cell. color is an ANSI color code, or empty.
+type cell struct {
+	text  string
+	color string
+}
+
+// printTable prints rows with each column padded to its widest cell.
+func printTable(rows [][]cell) {
+	var widths []int
+	for _, row := range rows {
+		for i, c := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			if n := utf8.RuneCountInString(c.text); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	for _, row := range rows {
+		cols := make([]string, len(row))
+		for i, c := range row {
+			text := c.text
+			if i < len(row)-1 {
+				text += strings.Repeat(" ", widths[i]-utf8.RuneCountInString(c.text))
+			}
+			cols[i] = paint(c.color, text)
+		}
+		fmt.Println(strings.Join(cols, "  "))
+	}
+}
+
+func paint(color, text string) string {
+	if !useColor || color == "" {
+		return text
+	}
+	return fmt.Sprintf("\x1b[%sm%s\x1b[0m", color, text)
+}
+
+// batteryCell shows level as a bar, colored by how full it is. Levels
+// above 100, which some feeds report, show as 100.
+func batteryCell(level int) cell {
+	if level < 0 {
+		return cell{text: "?", color: colorDim}
+	}
+	if level > 100 {
+		level = 100
+	}
+	const width = 5
+	filled := int(math.Round(float64(level) / 100 * width))
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+
+	color := colorGreen
+	switch {
+	case level < 30:
+		color = colorRed
+	case level < 60:
+		color = colorYellow
+	}
+	return cell{text: fmt.Sprintf("%s %3d%%", bar, level), color: color}
+}
+
+// directionCell shows the distance and direction from the origin to the
+// destination, e.g. "↗ 0.20 mi NE".
+func directionCell(fromLat, fromLng, toLat, toLng float64) cell {
+	bearing := geo.Bearing(fromLat, fromLng, toLat, toLng)
+	return cell{text: fmt.Sprintf("%s %0.2f mi %-2s",
+		arrow(bearing),
+		geo.Distance(fromLat, fromLng, toLat, toLng),
+		geo.CompassPoint(bearing),
+	)}
+}
+
+// arrow returns the arrow closest to the given compass bearing.
+func arrow(bearing float64) string {
+	arrows := []string{"↑", "↗", "→", "↘", "↓", "↙", "←", "↖"}
+	return arrows[int(math.Round(bearing/45))%len(arrows)]
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/themichaellai/bikealert/bikeshare"
+)
+
+// hubOverride changes how a hub is shown. Unset fields leave the upstream
+// data alone.
+type hubOverride struct {
+	// Name replaces the upstream name, e.g. "Work hub".
+	Name string `json:"name"`
+	// Latitude and Longitude pin the hub to e.g. its real entrance.
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+	// Hidden drops the hub from output.
+	Hidden bool `json:"hidden"`
+	// Lit marks the hub as well lit, which the night ranking profile
+	// prefers.
+	Lit bool `json:"lit"`
+}
+
+// loadHubOverrides reads the JSON file named by $HUB_OVERRIDES, which maps
+// hub IDs to overrides:
+//
+//	{"1234": {"name": "Work hub", "latitude": 37.78, "longitude": -122.40}}
+//
+// It returns nil if the env var is not set.
+func loadHubOverrides() (map[string]hubOverride, error) {
+	path, set := os.LookupEnv("HUB_OVERRIDES")
+	if !set {
+		return nil, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading hub overrides: %w", err)
+	}
+	defer f.Close()
+
+	var overrides map[string]hubOverride
+	if err := json.NewDecoder(f).Decode(&overrides); err != nil {
+		return nil, fmt.Errorf("error parsing hub overrides %s: %w", path, err)
+	}
+	return overrides, nil
+}
+
+// applyHubOverrides returns hubs with overrides merged on and hidden hubs
+// removed.
+func applyHubOverrides(hubs []bikeshare.Station, overrides map[string]hubOverride) []bikeshare.Station {
+	if len(overrides) == 0 {
+		return hubs
+	}
+	result := make([]bikeshare.Station, 0, len(hubs))
+	for _, hub := range hubs {
+		override, ok := overrides[hub.ID]
+		if !ok {
+			result = append(result, hub)
+			continue
+		}
+		if override.Hidden {
+			explainf("hub %s left out: hidden by overrides", hub.Name)
+			continue
+		}
+		if override.Name != "" {
+			explainf("hub %s renamed to %s by overrides", hub.Name, override.Name)
+			hub.Name = override.Name
+		}
+		if override.Latitude != nil && override.Longitude != nil {
+			explainf("hub %s moved to %f, %f by overrides", hub.Name, *override.Latitude, *override.Longitude)
+			hub.Latitude, hub.Longitude = *override.Latitude, *override.Longitude
+		}
+		result = append(result, hub)
+	}
+	return result
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/themichaellai/bikealert/address"
+	"github.com/themichaellai/bikealert/geo"
+	"github.com/themichaellai/bikealert/what3words"
+)
+
+// positionFormat is set by the --position flag.
+var positionFormat string
+
+var w3wClient *what3words.Client
+
+func setupPositionFormat() error {
+	switch positionFormat {
+	case "address", "pluscode":
+	case "w3w":
+		apiKey, set := os.LookupEnv("W3W_API_KEY")
+		if !set {
+			return fmt.Errorf("envvar \"W3W_API_KEY\" not set")
+		}
+		w3wClient = what3words.NewClient(apiKey)
+	default:
+		return usageErrorf("unknown position format \"%s\"", positionFormat)
+	}
+	return nil
+}
+
+// formatPosition shows a result's position as chosen by --position. If a
+// what3words lookup fails, it falls back to the address.
+func formatPosition(addr string, lat, lng float64) string {
+	switch positionFormat {
+	case "pluscode":
+		return geo.PlusCode(lat, lng)
+	case "w3w":
+		words, err := w3wClient.Words(lat, lng)
+		if err == nil {
+			return "///" + words
+		}
+		fmt.Fprintf(os.Stderr, "error looking up what3words address: %s\n", err.Error())
+	}
+	return formatAddress(addr)
+}
+
+// formatAddress shortens addr for display using the locale in
+// $ADDRESS_LOCALE. Setting it to "full" shows addresses unchanged.
+func formatAddress(addr string) string {
+	locale := os.Getenv("ADDRESS_LOCALE")
+	if locale == "full" {
+		return addr
+	}
+	return address.Shorten(addr, locale)
+}
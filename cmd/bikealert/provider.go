@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/themichaellai/bikealert/bikeshare"
+	"github.com/themichaellai/bikealert/jump"
+
+	// Registered providers.
+	_ "github.com/themichaellai/bikealert/gbfs"
+)
+
+// providerFlag and networkFlag are set by --provider and --network.
+var providerFlag, networkFlag string
+
+// newProvider opens the bikeshare provider named by --provider or
+// $PROVIDER. It defaults to "gbfs" if $GBFS_URL is set, and "jump"
+// otherwise.
+//
+// Each provider's settings come from env vars prefixed with its name, so
+// $JUMP_AUTH_TOKEN is the "auth_token" setting for "jump" and $GBFS_URL
+// the "url" setting for "gbfs", falling back to the config file. --network
+// sets the "network" setting.
+func newProvider() (bikeshare.Provider, error) {
+	return openProvider(nil)
+}
+
+const (
+	// pollMaxConcurrency is how many requests countdown and daemon polls
+	// keep in flight, to go easy on the upstream host over a long run.
+	pollMaxConcurrency = 1
+	// defaultHubsTTL is how long polls and the server reuse hubs. Hubs
+	// rarely move, but their counts change, so it is kept short.
+	defaultHubsTTL = 5 * time.Minute
+)
+
+// newPollingProvider is like newProvider, for long-running poll loops. It
+// keeps pollMaxConcurrency requests in flight and reuses hubs for
+// defaultHubsTTL, unless the provider's max_concurrency and hubs_ttl
+// settings say otherwise.
+func newPollingProvider() (bikeshare.Provider, error) {
+	return openProvider(map[string]string{
+		"max_concurrency": strconv.Itoa(pollMaxConcurrency),
+		"hubs_ttl":        defaultHubsTTL.String(),
+	})
+}
+
+// openProvider opens the provider newProvider describes, using defaults
+// for settings that aren't otherwise set. The "bikes_ttl" and "hubs_ttl"
+// settings, durations such as "30s", wrap it in a bikeshare.Cache that
+// reuses bikes and hubs for that long.
+func openProvider(defaults map[string]string) (bikeshare.Provider, error) {
+	name := providerName()
+	settings := providerSettings(name)
+	for key, val := range defaults {
+		if _, ok := settings[key]; !ok {
+			settings[key] = val
+		}
+	}
+	bikesTTL, err := settingDuration(settings, "bikes_ttl")
+	if err != nil {
+		return nil, err
+	}
+	hubsTTL, err := settingDuration(settings, "hubs_ttl")
+	if err != nil {
+		return nil, err
+	}
+	provider, err := bikeshare.Open(name, settings)
+	if err != nil {
+		return nil, err
+	}
+	if bikesTTL <= 0 && hubsTTL <= 0 {
+		return provider, nil
+	}
+	return bikeshare.NewCache(provider, bikesTTL, hubsTTL, clk), nil
+}
+
+// settingDuration parses a provider setting as a duration, returning 0 if
+// it is not set.
+func settingDuration(settings map[string]string, key string) (time.Duration, error) {
+	val, ok := settings[key]
+	if !ok {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, fmt.Errorf("error parsing \"%s\" setting as duration: %w", key, err)
+	}
+	return d, nil
+}
+
+// providerName returns the name of the provider newProvider opens.
+func providerName() string {
+	if providerFlag != "" {
+		return providerFlag
+	}
+	if name, set := os.LookupEnv("PROVIDER"); set {
+		return name
+	}
+	if cfg.provider != "" {
+		return cfg.provider
+	}
+	if _, set := os.LookupEnv("GBFS_URL"); set {
+		return "gbfs"
+	}
+	return jump.ProviderName
+}
+
+// providerSettings collects the env vars prefixed with the provider's
+// name, e.g. $BAY_WHEELS_API_KEY becomes "api_key" for "bay-wheels".
+func providerSettings(name string) map[string]string {
+	prefix := strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+	settings := map[string]string{}
+	for key, val := range cfg.settings {
+		settings[key] = val
+	}
+	if cfg.network != "" {
+		settings["network"] = cfg.network
+	}
+	if seed != 0 {
+		settings["seed"] = strconv.FormatInt(seed, 10)
+	}
+	for _, env := range os.Environ() {
+		key, val, _ := strings.Cut(env, "=")
+		if strings.HasPrefix(key, prefix) {
+			settings[strings.ToLower(strings.TrimPrefix(key, prefix))] = val
+		}
+	}
+	if networkFlag != "" {
+		settings["network"] = networkFlag
+	}
+	return settings
+}
+
+// statsProvider is a provider that counts its requests, like JUMP's.
+type statsProvider interface {
+	Stats() jump.Stats
+}
+
+// providerStats returns provider as a statsProvider, looking through a
+// bikeshare.Cache, if it keeps request counters.
+func providerStats(provider bikeshare.Provider) (statsProvider, bool) {
+	if cache, ok := provider.(*bikeshare.Cache); ok {
+		provider = cache.Unwrap()
+	}
+	p, ok := provider.(statsProvider)
+	return p, ok
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/themichaellai/bikealert/bikeshare"
+	"github.com/themichaellai/bikealert/geo"
+)
+
+// rankProfile controls how bikes are ranked.
+type rankProfile struct {
+	name string
+	// batteryWeight is how much further an empty bike counts as than a
+	// full one at the same distance, e.g. 0.5 ranks an empty bike as if it
+	// were 50% further away. 0 ranks by distance only.
+	batteryWeight float64
+	// unlitWeight is how much further a hub not marked lit in
+	// $HUB_OVERRIDES counts as than a lit one at the same distance. 0
+	// ranks hubs by distance only.
+	unlitWeight float64
+}
+
+var rankProfiles = map[string]rankProfile{
+	// distance ranks by straight-line distance only.
+	"distance": {name: "distance"},
+	// day trades some distance for a fuller battery.
+	"day": {name: "day", batteryWeight: 0.5},
+	// night keeps the walk as short as possible, whatever the battery,
+	// and prefers well-lit hubs.
+	"night": {name: "night", unlitWeight: 0.5},
+}
+
+// rankProfileName is set by the --profile flag.
+var rankProfileName string
+
+// rankWindow applies a profile between two times of day.
+type rankWindow struct {
+	profile    rankProfile
+	start, end time.Duration
+}
+
+// rankSchedule is parsed from $RANK_SCHEDULE, or the config's
+// rank_schedule.
+var rankSchedule []rankWindow
+
+// setupRankProfile checks --profile and sets up the schedule.
+//
+// Outside every window, the --profile profile is used.
+func setupRankProfile() error {
+	if _, ok := rankProfiles[rankProfileName]; !ok {
+		return usageErrorf("unknown ranking profile \"%s\"", rankProfileName)
+	}
+	val, set := os.LookupEnv("RANK_SCHEDULE")
+	if !set {
+		rankSchedule = cfg.rankSchedule
+		return nil
+	}
+	schedule, err := parseRankSchedule(val)
+	if err != nil {
+		return fmt.Errorf("error parsing env var \"RANK_SCHEDULE\": %w", err)
+	}
+	rankSchedule = schedule
+	return nil
+}
+
+// parseRankSchedule parses a comma-separated list of profiles and the
+// times of day they apply:
+//
+//	night=21:00-06:00,day=06:00-21:00
+func parseRankSchedule(val string) ([]rankWindow, error) {
+	var schedule []rankWindow
+	for _, entry := range strings.Split(val, ",") {
+		name, window, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			return nil, fmt.Errorf("expected profile=HH:MM-HH:MM, got \"%s\"", entry)
+		}
+		profile, ok := rankProfiles[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown ranking profile \"%s\"", name)
+		}
+		startStr, endStr, ok := strings.Cut(window, "-")
+		if !ok {
+			return nil, fmt.Errorf("expected HH:MM-HH:MM, got \"%s\"", window)
+		}
+		start, err := parseTimeOfDay(startStr)
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseTimeOfDay(endStr)
+		if err != nil {
+			return nil, err
+		}
+		schedule = append(schedule, rankWindow{profile: profile, start: start, end: end})
+	}
+	return schedule, nil
+}
+
+// currentRankProfile returns the profile that applies now. It is looked up
+// on every ranking so long-running commands switch profiles on schedule.
+func currentRankProfile() rankProfile {
+	now := clk.Now()
+	sinceMidnight := time.Duration(now.Hour())*time.Hour + time.Duration(now.Minute())*time.Minute
+	for _, window := range rankSchedule {
+		if window.contains(sinceMidnight) {
+			return window.profile
+		}
+	}
+	return rankProfiles[rankProfileName]
+}
+
+// contains reports whether a time of day falls in the window. Windows may
+// wrap past midnight.
+func (w rankWindow) contains(t time.Duration) bool {
+	if w.start <= w.end {
+		return w.start <= t && t < w.end
+	}
+	return t >= w.start || t < w.end
+}
+
+// score is a vehicle's effective distance in miles under the profile;
+// lower ranks first. Vehicles with unknown battery count as half full.
+func (p rankProfile) score(vehicle bikeshare.Vehicle, latitude, longitude float64) float64 {
+	distance := geo.Distance(latitude, longitude, vehicle.Latitude, vehicle.Longitude)
+	if p.batteryWeight == 0 {
+		return distance
+	}
+	full := 0.5
+	if vehicle.BatteryLevel >= 0 {
+		full = float64(vehicle.BatteryLevel) / 100
+	}
+	return distance * (1 + p.batteryWeight*(1-full))
+}
+
+// hubScore is a hub's effective distance in miles under the profile;
+// lower ranks first.
+func (p rankProfile) hubScore(hub bikeshare.Station, lit bool, latitude, longitude float64) float64 {
+	distance := hubDistance(hub, latitude, longitude)
+	if lit {
+		return distance
+	}
+	return distance * (1 + p.unlitWeight)
+}
+
+// parseTimeOfDay parses "HH:MM" as the time since midnight.
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day \"%s\"", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRankSchedule(t *testing.T) {
+	tests := []struct {
+		val     string
+		want    []rankWindow
+		wantErr bool
+	}{
+		{
+			val:  "night=21:00-06:00,day=06:00-21:00",
+			want: []rankWindow{{rankProfiles["night"], 21 * time.Hour, 6 * time.Hour}, {rankProfiles["day"], 6 * time.Hour, 21 * time.Hour}},
+		},
+		{
+			val:  " distance=07:30-08:15 ",
+			want: []rankWindow{{rankProfiles["distance"], 7*time.Hour + 30*time.Minute, 8*time.Hour + 15*time.Minute}},
+		},
+		{val: "night", wantErr: true},
+		{val: "dusk=21:00-06:00", wantErr: true},
+		{val: "night=21:00", wantErr: true},
+		{val: "night=9pm-6am", wantErr: true},
+		{val: "night=21:00-25:00", wantErr: true},
+		{val: "night=21:00-06:00,", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.val, func(t *testing.T) {
+			got, err := parseRankSchedule(tt.val)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRankSchedule() error = %v, want error %t", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseRankSchedule() = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("window %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRankWindowContains(t *testing.T) {
+	day := rankWindow{start: 6 * time.Hour, end: 21 * time.Hour}
+	night := rankWindow{start: 21 * time.Hour, end: 6 * time.Hour}
+	tests := []struct {
+		at        time.Duration
+		wantDay   bool
+		wantNight bool
+	}{
+		{at: 0, wantNight: true},
+		{at: 5*time.Hour + 59*time.Minute, wantNight: true},
+		{at: 6 * time.Hour, wantDay: true},
+		{at: 12 * time.Hour, wantDay: true},
+		{at: 21 * time.Hour, wantNight: true},
+		{at: 23*time.Hour + 59*time.Minute, wantNight: true},
+	}
+	for _, tt := range tests {
+		if got := day.contains(tt.at); got != tt.wantDay {
+			t.Errorf("day window contains %s = %t, want %t", tt.at, got, tt.wantDay)
+		}
+		if got := night.contains(tt.at); got != tt.wantNight {
+			t.Errorf("night window contains %s = %t, want %t", tt.at, got, tt.wantNight)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// runReportBroken adds a bike to the ignore list and prints a maintenance
+// report to send to the operator. JUMP has no public endpoint for
+// reports, so this drafts an email instead of filing one.
+func runReportBroken(args []string) error {
+	if len(args) != 1 {
+		return usageErrorf("usage: bikealert report-broken <bike name>")
+	}
+	name := args[0]
+
+	location := "unknown"
+	// The origin only narrows the search for providers that support it,
+	// so it's fine for it to be unset.
+	latitude, longitude, _ := getOrigin()
+	provider, err := newProvider()
+	if err != nil {
+		return err
+	}
+	vehicles, err := fetchVehicles(provider, latitude, longitude)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not look up bike location: %s\n", err.Error())
+	}
+	for _, vehicle := range vehicles {
+		if vehicle.Name == name {
+			location = fmt.Sprintf("%s (%f, %f)", vehicle.Address, vehicle.Latitude, vehicle.Longitude)
+			break
+		}
+	}
+
+	if err := ignoreBike(name); err != nil {
+		return fmt.Errorf("error adding bike to ignore list: %w", err)
+	}
+
+	if to, set := os.LookupEnv("REPORT_EMAIL"); set {
+		fmt.Printf("To: %s\n", to)
+	}
+	fmt.Printf("Subject: Broken bike %s\n\n", name)
+	fmt.Printf("Bike %s needs maintenance.\nLast reported location: %s\n", name, location)
+	fmt.Fprintf(os.Stderr, "\nBike %s will be left out of results from now on.\n", name)
+	return nil
+}
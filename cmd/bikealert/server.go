@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/themichaellai/bikealert/bikeshare"
+)
+
+const (
+	defaultServerAddr = ":8080"
+	// maxServerLimit caps ?limit=, so one request can't ask for a whole
+	// network's worth of JSON.
+	maxServerLimit        = 100
+	serverShutdownTimeout = 5 * time.Second
+	// defaultServerBikesTTL is how long the server reuses bikes between
+	// requests, unless the provider's bikes_ttl setting says otherwise.
+	defaultServerBikesTTL = 30 * time.Second
+)
+
+var (
+	serverFlags = newLocationFlagSet("server")
+	serverAddr  = serverFlags.String("addr", "", "address to listen on (default $SERVER_ADDR or :8080)")
+)
+
+// apiServer answers the JSON API over a shared, cached provider.
+type apiServer struct {
+	provider     bikeshare.Provider
+	ignoredBikes map[string]bool
+	hubOverrides map[string]hubOverride
+	// hasOrigin is whether requests without lat and lng can use latitude
+	// and longitude, the CLI's origin resolved once at startup.
+	hasOrigin           bool
+	latitude, longitude float64
+}
+
+// runServer serves the closest bikes and hubs as JSON until interrupted:
+//
+//	GET /v1/bikes?lat=37.776&lng=-122.418&limit=5
+//	GET /v1/hubs?lat=37.776&lng=-122.418&limit=5
+//
+// Without lat and lng, requests use the CLI's origin. It is looked up once
+// at startup, so an --address doesn't cost a geocoding request each time.
+func runServer(args []string) error {
+	addr := *serverAddr
+	if addr == "" {
+		addr = os.Getenv("SERVER_ADDR")
+	}
+	if addr == "" {
+		addr = defaultServerAddr
+	}
+	ignoredBikes, err := loadIgnoredBikes()
+	if err != nil {
+		return err
+	}
+	hubOverrides, err := loadHubOverrides()
+	if err != nil {
+		return err
+	}
+	provider, err := openProvider(map[string]string{
+		"bikes_ttl": defaultServerBikesTTL.String(),
+		"hubs_ttl":  defaultHubsTTL.String(),
+	})
+	if err != nil {
+		return err
+	}
+	api := &apiServer{
+		provider:     provider,
+		ignoredBikes: ignoredBikes,
+		hubOverrides: hubOverrides,
+	}
+	if api.latitude, api.longitude, err = getOrigin(); err == nil {
+		api.hasOrigin = true
+		daemonf("requests without lat and lng search around %f, %f", api.latitude, api.longitude)
+	} else {
+		daemonf("requests must give lat and lng: %s", err.Error())
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/bikes", api.handleBikes)
+	mux.HandleFunc("/v1/hubs", api.handleHubs)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("error listening for API requests: %w", err)
+	}
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: serverShutdownTimeout}
+	go server.Serve(listener)
+	daemonf("serving the API on %s", listener.Addr())
+
+	<-ctx.Done()
+	daemonf("shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serverShutdownTimeout)
+	defer cancel()
+	return server.Shutdown(shutdownCtx)
+}
+
+func (a *apiServer) handleBikes(w http.ResponseWriter, r *http.Request) {
+	latitude, longitude, limit, ok := a.parseQuery(w, r)
+	if !ok {
+		return
+	}
+	vehicles, err := a.provider.NearbyVehicles(r.Context(), latitude, longitude)
+	if err != nil {
+		writeAPIError(w, http.StatusBadGateway, "error fetching bikes: "+err.Error())
+		return
+	}
+	vehicles = removeIgnored(vehicles, a.ignoredBikes)
+	sortVehicles(vehicles, latitude, longitude)
+	writeAPIResponse(w, map[string]interface{}{
+		"bikes": newVehicleResults(firstVehicles(vehicles, limit), latitude, longitude),
+	})
+}
+
+func (a *apiServer) handleHubs(w http.ResponseWriter, r *http.Request) {
+	latitude, longitude, limit, ok := a.parseQuery(w, r)
+	if !ok {
+		return
+	}
+	hubs, err := a.provider.NearbyStations(r.Context(), latitude, longitude)
+	if err != nil {
+		writeAPIError(w, http.StatusBadGateway, "error fetching hubs: "+err.Error())
+		return
+	}
+	hubs = applyHubOverrides(hubs, a.hubOverrides)
+	sortHubs(hubs, a.hubOverrides, latitude, longitude)
+	writeAPIResponse(w, map[string]interface{}{
+		"hubs": newStationResults(firstHubs(hubs, limit), latitude, longitude),
+	})
+}
+
+// parseQuery reads lat, lng and limit from a GET request. If they are
+// invalid it writes an error response and returns ok false.
+func (a *apiServer) parseQuery(w http.ResponseWriter, r *http.Request) (latitude, longitude float64, limit int, ok bool) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeAPIError(w, http.StatusMethodNotAllowed, "only GET is supported")
+		return 0, 0, 0, false
+	}
+	q := r.URL.Query()
+	var err error
+	if q.Get("lat") == "" && q.Get("lng") == "" {
+		if !a.hasOrigin {
+			writeAPIError(w, http.StatusBadRequest, "lat and lng are required")
+			return 0, 0, 0, false
+		}
+		latitude, longitude = a.latitude, a.longitude
+	} else {
+		latitude, err = strconv.ParseFloat(q.Get("lat"), 64)
+		if err != nil || math.IsNaN(latitude) || latitude < -90 || latitude > 90 {
+			writeAPIError(w, http.StatusBadRequest, "lat must be a number from -90 to 90")
+			return 0, 0, 0, false
+		}
+		longitude, err = strconv.ParseFloat(q.Get("lng"), 64)
+		if err != nil || math.IsNaN(longitude) || longitude < -180 || longitude > 180 {
+			writeAPIError(w, http.StatusBadRequest, "lng must be a number from -180 to 180")
+			return 0, 0, 0, false
+		}
+	}
+
+	limit = defaultLimit
+	if val := q.Get("limit"); val != "" {
+		limit, err = strconv.Atoi(val)
+		if err != nil || limit < 1 || limit > maxServerLimit {
+			writeAPIError(w, http.StatusBadRequest, fmt.Sprintf("limit must be from 1 to %d", maxServerLimit))
+			return 0, 0, 0, false
+		}
+	}
+	return latitude, longitude, limit, true
+}
+
+func writeAPIResponse(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeAPIError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+
+	"github.com/themichaellai/bikealert/geo"
+)
+
+// geoJSONFeature is a GeoJSON point feature.
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   geoJSONPoint           `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geoJSONPoint struct {
+	Type string `json:"type"`
+	// Coordinates are longitude, latitude.
+	Coordinates [2]float64 `json:"coordinates"`
+}
+
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+func newPointFeature(latitude, longitude float64, properties map[string]interface{}) geoJSONFeature {
+	return geoJSONFeature{
+		Type: "Feature",
+		Geometry: geoJSONPoint{
+			Type:        "Point",
+			Coordinates: [2]float64{longitude, latitude},
+		},
+		Properties: properties,
+	}
+}
+
+// siteRow is one line of a table on the exported page.
+type siteRow struct {
+	Name     string
+	Detail   string
+	Distance string
+	Address  string
+}
+
+var siteTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>bikealert</title>
+<style>
+body { font-family: sans-serif; max-width: 40em; margin: 1em auto; padding: 0 1em; }
+td, th { padding: 0.2em 0.6em; text-align: left; }
+</style>
+</head>
+<body>
+<h1>Bikes nearby</h1>
+<p>Updated {{.Updated}}.</p>
+<table>
+<tr><th>Bike</th><th>Battery</th><th>Distance</th><th>Address</th></tr>
+{{range .Bikes}}<tr><td>{{.Name}}</td><td>{{.Detail}}</td><td>{{.Distance}}</td><td>{{.Address}}</td></tr>
+{{end}}</table>
+<h1>Hubs nearby</h1>
+<table>
+<tr><th>Hub</th><th>Bikes</th><th>Distance</th><th>Address</th></tr>
+{{range .Hubs}}<tr><td>{{.Name}}</td><td>{{.Detail}}</td><td>{{.Distance}}</td><td>{{.Address}}</td></tr>
+{{end}}</table>
+<p>Raw data: <a href="bikes.geojson">bikes.geojson</a>, <a href="hubs.geojson">hubs.geojson</a>.</p>
+</body>
+</html>
+`))
+
+var (
+	exportSiteFlags = newLocationFlagSet("export-site")
+	exportSiteOut   = exportSiteFlags.String("out", "", "directory to write the site to")
+	exportSiteLimit = limitFlag(exportSiteFlags, 20, "`number` of bikes and hubs to list on the page")
+)
+
+// runExportSite writes a static HTML page listing availability near the
+// origin, plus GeoJSON of every bike and hub, for publishing without a
+// server.
+func runExportSite(args []string) error {
+	if *exportSiteOut == "" {
+		return usageErrorf("usage: bikealert export-site --out dir/")
+	}
+
+	latitude, longitude, err := getOrigin()
+	if err != nil {
+		return err
+	}
+	hubOverrides, err := loadHubOverrides()
+	if err != nil {
+		return err
+	}
+	ignoredBikes, err := loadIgnoredBikes()
+	if err != nil {
+		return err
+	}
+
+	provider, err := newProvider()
+	if err != nil {
+		return err
+	}
+	vehicles, hubs, err := fetchAll(provider, latitude, longitude)
+	if err != nil {
+		return err
+	}
+	vehicles = removeIgnored(vehicles, ignoredBikes)
+	hubs = applyHubOverrides(hubs, hubOverrides)
+	sortVehicles(vehicles, latitude, longitude)
+	sortHubs(hubs, hubOverrides, latitude, longitude)
+
+	bikeFeatures := geoJSONFeatureCollection{Type: "FeatureCollection", Features: []geoJSONFeature{}}
+	var bikeRows []siteRow
+	for _, vehicle := range vehicles {
+		bikeFeatures.Features = append(bikeFeatures.Features, newPointFeature(vehicle.Latitude, vehicle.Longitude, map[string]interface{}{
+			"id":            vehicle.ID,
+			"name":          vehicle.Name,
+			"type":          vehicle.Type,
+			"battery_level": vehicle.BatteryLevel,
+			"address":       vehicle.Address,
+		}))
+		if len(bikeRows) < *exportSiteLimit {
+			bikeRows = append(bikeRows, siteRow{
+				Name:     vehicle.Name,
+				Detail:   formatBattery(vehicle.BatteryLevel),
+				Distance: fmt.Sprintf("%0.2f mi", geo.Distance(latitude, longitude, vehicle.Latitude, vehicle.Longitude)),
+				Address:  formatAddress(vehicle.Address),
+			})
+		}
+	}
+
+	hubFeatures := geoJSONFeatureCollection{Type: "FeatureCollection", Features: []geoJSONFeature{}}
+	var hubRows []siteRow
+	for _, hub := range hubs {
+		hubFeatures.Features = append(hubFeatures.Features, newPointFeature(hub.Latitude, hub.Longitude, map[string]interface{}{
+			"id":              hub.ID,
+			"name":            hub.Name,
+			"available_bikes": hub.AvailableVehicles,
+			"free_racks":      hub.AvailableDocks,
+			"address":         hub.Address,
+		}))
+		if len(hubRows) < *exportSiteLimit {
+			hubRows = append(hubRows, siteRow{
+				Name:     hub.Name,
+				Detail:   fmt.Sprintf("%d", hub.AvailableVehicles),
+				Distance: fmt.Sprintf("%0.2f mi", hubDistance(hub, latitude, longitude)),
+				Address:  formatAddress(hub.Address),
+			})
+		}
+	}
+
+	if err := os.MkdirAll(*exportSiteOut, 0755); err != nil {
+		return err
+	}
+	if err := writeJSONFile(filepath.Join(*exportSiteOut, "bikes.geojson"), bikeFeatures); err != nil {
+		return err
+	}
+	if err := writeJSONFile(filepath.Join(*exportSiteOut, "hubs.geojson"), hubFeatures); err != nil {
+		return err
+	}
+
+	f, err := os.Create(filepath.Join(*exportSiteOut, "index.html"))
+	if err != nil {
+		return err
+	}
+	err = siteTemplate.Execute(f, map[string]interface{}{
+		"Updated": clk.Now().Format("Mon Jan 2 15:04 MST"),
+		"Bikes":   bikeRows,
+		"Hubs":    hubRows,
+	})
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func writeJSONFile(path string, v interface{}) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(f).Encode(v); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/themichaellai/bikealert/geo"
+	"github.com/themichaellai/bikealert/internal/units"
+	"github.com/themichaellai/bikealert/store"
+)
+
+var (
+	statsFlags  = newLocationFlagSet("stats")
+	statsHub    = statsFlags.String("hub", "", "only show hubs whose ID is this or whose name contains it")
+	statsRadius = statsFlags.String("radius", "1mi",
+		"only show hubs this close to the origin, e.g. 800m; 0 shows hubs at any distance")
+	statsAt = statsFlags.String("at", "",
+		"predict the chance of a bike at this time of day, e.g. 08:45, at its next occurrence")
+	statsSince = statsFlags.Duration("since", defaultStatsSince, "only use history this recent; 0 uses all of it")
+)
+
+// defaultStatsSince bounds how much history stats reads unless asked for
+// more, since it is all loaded into memory.
+const defaultStatsSince = 7 * 24 * time.Hour
+
+// runStats shows how hub availability in the stored history varies by
+// hour of the day, or with --at, the chance each hub has a bike then.
+func runStats(args []string) error {
+	st, err := openStore()
+	if err != nil {
+		return err
+	}
+	if st == nil {
+		return fmt.Errorf("no history to analyze; set $STORE_DIR and run the daemon to record some")
+	}
+	radius, err := units.ParseDistance(*statsRadius)
+	if err != nil {
+		return usageErrorf("error parsing --radius: %s", err.Error())
+	}
+	var latitude, longitude float64
+	if radius > 0 {
+		if latitude, longitude, err = getOrigin(); err != nil {
+			return fmt.Errorf("%w; give an origin, or --radius 0 for hubs at any distance", err)
+		}
+	}
+	var at time.Time
+	if *statsAt != "" {
+		if at, err = nextTimeOfDay(*statsAt); err != nil {
+			return fmt.Errorf("error parsing --at: %w", err)
+		}
+	}
+
+	now := clk.Now()
+	from := time.Time{}
+	if *statsSince > 0 {
+		from = now.Add(-*statsSince)
+	}
+	snaps, err := st.Snapshots(from, now.Add(time.Nanosecond))
+	if err != nil {
+		return err
+	}
+	var trends []*store.HubTrend
+	for _, trend := range store.Trends(snaps, time.Local) {
+		if radius > 0 && geo.Distance(latitude, longitude, trend.Latitude, trend.Longitude) > radius {
+			continue
+		}
+		if *statsHub == "" || trend.ID == *statsHub ||
+			strings.Contains(strings.ToLower(trend.Name), strings.ToLower(*statsHub)) {
+			trends = append(trends, trend)
+		}
+	}
+	if len(trends) == 0 {
+		return fmt.Errorf("no matching hubs in history from %d snapshots", len(snaps))
+	}
+
+	if !at.IsZero() {
+		printPredictions(trends, at)
+	} else {
+		printHourlyTrends(trends)
+	}
+	return nil
+}
+
+// printPredictions prints one line per hub with its chance of a bike at t.
+func printPredictions(trends []*store.HubTrend, t time.Time) {
+	fmt.Printf("Chance of a bike at %s on %s\n", t.Format("15:04"), t.Weekday())
+	var rows [][]cell
+	for _, trend := range trends {
+		a := trend.Predict(t)
+		if a.Samples == 0 {
+			rows = append(rows, []cell{{text: "?"}, {text: trend.Name}, {text: "no samples at this hour"}})
+			continue
+		}
+		basis := "every day"
+		if trend.Slots[store.Slot{Weekday: t.Weekday(), Hour: t.Hour()}].Samples >= store.MinPredictionSamples {
+			basis = t.Weekday().String() + "s"
+		}
+		rows = append(rows, []cell{
+			chanceCell(a.Chance()),
+			{text: trend.Name},
+			{text: fmt.Sprintf("%0.1f bikes avg, %d samples from %s", a.Mean(), a.Samples, basis)},
+		})
+	}
+	printTable(rows)
+}
+
+// printHourlyTrends prints each hub's availability by hour of the day,
+// across every day of the week.
+func printHourlyTrends(trends []*store.HubTrend) {
+	for i, trend := range trends {
+		if i > 0 {
+			fmt.Println("")
+		}
+		fmt.Printf("%s (%s)\n", trend.Name, trend.ID)
+		var rows [][]cell
+		for hour := 0; hour < 24; hour++ {
+			a := trend.Hour(hour)
+			if a.Samples == 0 {
+				continue
+			}
+			rows = append(rows, []cell{
+				{text: fmt.Sprintf("%02d:00", hour)},
+				chanceCell(a.Chance()),
+				{text: fmt.Sprintf("%0.1f bikes avg", a.Mean())},
+				{text: fmt.Sprintf("%d samples", a.Samples)},
+			})
+		}
+		printTable(rows)
+	}
+}
+
+// chanceCell shows a chance of finding a bike as a bar, like a battery
+// level.
+func chanceCell(chance float64) cell {
+	return batteryCell(int(math.Round(chance * 100)))
+}
+
+// nextTimeOfDay returns the next time the clock reads s, e.g. "08:45":
+// today if that hasn't passed yet, and tomorrow otherwise.
+func nextTimeOfDay(s string) (time.Time, error) {
+	offset, err := parseTimeOfDay(s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	now := clk.Now()
+	t := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).Add(offset)
+	if t.Before(now) {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
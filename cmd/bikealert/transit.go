@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/themichaellai/bikealert/gtfs"
+)
+
+// transitDepartures is how many upcoming departures to show.
+const transitDepartures = 3
+
+// walkingSpeed is in miles per hour, used to estimate the walk to a stop.
+const walkingSpeed = 3.0
+
+// printTransit prints the next departures from the transit stop nearest
+// the given coordinates, using the GTFS static feed zip at path.
+func printTransit(path string, latitude, longitude float64) error {
+	feed, err := gtfs.Open(path)
+	if err != nil {
+		return err
+	}
+	stop, distance, err := feed.NearestStop(latitude, longitude)
+	if err != nil {
+		return err
+	}
+	now := clk.Now()
+	departures, err := feed.NextDepartures(stop, now, transitDepartures)
+	if err != nil {
+		return err
+	}
+	walk := time.Duration(distance / walkingSpeed * float64(time.Hour))
+	explainf("nearest transit stop %s is %0.2f mi away, about %s on foot", stop.Name, distance, formatRemaining(walk))
+
+	fmt.Printf("Transit from %s\n", stop.Name)
+	if len(departures) == 0 {
+		fmt.Println("no more departures today")
+		return nil
+	}
+	var rows [][]cell
+	for _, departure := range departures {
+		wait := departure.Time.Sub(now)
+		// Departures that leave before we could walk there are shown
+		// dimmed.
+		color := ""
+		if wait < walk {
+			color = colorDim
+		}
+		rows = append(rows, []cell{
+			directionCell(latitude, longitude, stop.Latitude, stop.Longitude),
+			{text: fmt.Sprintf("in %s", formatRemaining(wait)), color: color},
+			{text: departure.RouteName},
+			{text: departure.Headsign},
+		})
+	}
+	printTable(rows)
+	return nil
+}
@@ -0,0 +1,352 @@
+// Package gbfs is a client for bikeshare systems that publish General
+// Bikeshare Feed Specification (GBFS) v1 or v2 feeds.
+package gbfs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrUnexpectedStatus means a feed responded with a non-200 status.
+	ErrUnexpectedStatus = errors.New("unexpected status code")
+	// ErrFeedNotFound means the system's gbfs.json doesn't list the feed.
+	ErrFeedNotFound = errors.New("feed not published")
+)
+
+const httpTimeout = 5 * time.Second
+
+// Client has methods for accessing a GBFS system's feeds.
+type Client struct {
+	discoveryURL string
+	language     string
+	httpClient   *http.Client
+	// sem limits concurrent requests when non-nil.
+	sem chan struct{}
+
+	feedsMu sync.Mutex
+	// feeds maps feed names to URLs, once gbfs.json has been read.
+	feeds map[string]string
+}
+
+// NewClient creates a client for the system whose auto-discovery file
+// (gbfs.json) is at discoveryURL.
+func NewClient(discoveryURL string, opts ...Option) *Client {
+	c := &Client{
+		discoveryURL: discoveryURL,
+		language:     "en",
+		httpClient:   &http.Client{Timeout: httpTimeout},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Bike is a vehicle that isn't parked at a station, from
+// free_bike_status.json.
+type Bike struct {
+	BikeID        string  `json:"bike_id"`
+	Lat           float64 `json:"lat"`
+	Lon           float64 `json:"lon"`
+	IsReserved    flag    `json:"is_reserved"`
+	IsDisabled    flag    `json:"is_disabled"`
+	VehicleTypeID string  `json:"vehicle_type_id"`
+	// CurrentRangeMeters is 0 if the system doesn't report it.
+	CurrentRangeMeters float64 `json:"current_range_meters"`
+	// CurrentFuelPercent is between 0 and 1, or nil if the system doesn't
+	// report it.
+	CurrentFuelPercent *float64 `json:"current_fuel_percent"`
+	// PricingPlanID refers to a plan in system_pricing_plans.json, or is
+	// empty if the system doesn't say.
+	PricingPlanID string `json:"pricing_plan_id"`
+}
+
+// Bikes retrieves the system's free-floating bikes.
+func (c *Client) Bikes() ([]Bike, error) {
+	return c.BikesContext(context.Background())
+}
+
+// BikesContext is like Bikes, but gives up when ctx is done.
+//
+// free_bike_status is optional, and dock-only systems don't publish it.
+// For them BikesContext returns no bikes rather than ErrFeedNotFound.
+func (c *Client) BikesContext(ctx context.Context) ([]Bike, error) {
+	errPrefix := "gbfs.Bikes"
+
+	var feed struct {
+		Bikes []Bike `json:"bikes"`
+	}
+	if err := c.getFeed(ctx, "free_bike_status", &feed); errors.Is(err, ErrFeedNotFound) {
+		return []Bike{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("%s: %w", errPrefix, err)
+	}
+	return feed.Bikes, nil
+}
+
+// StationInformation is a station's mostly static details, from
+// station_information.json.
+type StationInformation struct {
+	StationID string  `json:"station_id"`
+	Name      string  `json:"name"`
+	Lat       float64 `json:"lat"`
+	Lon       float64 `json:"lon"`
+	Address   string  `json:"address"`
+	Capacity  int     `json:"capacity"`
+}
+
+// StationInformation retrieves details of every station in the system.
+func (c *Client) StationInformation() ([]StationInformation, error) {
+	return c.StationInformationContext(context.Background())
+}
+
+// StationInformationContext is like StationInformation, but gives up when
+// ctx is done.
+func (c *Client) StationInformationContext(ctx context.Context) ([]StationInformation, error) {
+	errPrefix := "gbfs.StationInformation"
+
+	var feed struct {
+		Stations []StationInformation `json:"stations"`
+	}
+	if err := c.getFeed(ctx, "station_information", &feed); err != nil {
+		return nil, fmt.Errorf("%s: %w", errPrefix, err)
+	}
+	return feed.Stations, nil
+}
+
+// StationStatus is a station's current availability, from
+// station_status.json.
+type StationStatus struct {
+	StationID         string `json:"station_id"`
+	NumBikesAvailable int    `json:"num_bikes_available"`
+	NumDocksAvailable int    `json:"num_docks_available"`
+	IsInstalled       flag   `json:"is_installed"`
+	IsRenting         flag   `json:"is_renting"`
+	IsReturning       flag   `json:"is_returning"`
+	LastReported      int64  `json:"last_reported"`
+}
+
+// StationStatus retrieves the availability of every station in the
+// system.
+func (c *Client) StationStatus() ([]StationStatus, error) {
+	return c.StationStatusContext(context.Background())
+}
+
+// StationStatusContext is like StationStatus, but gives up when ctx is
+// done.
+func (c *Client) StationStatusContext(ctx context.Context) ([]StationStatus, error) {
+	errPrefix := "gbfs.StationStatus"
+
+	var feed struct {
+		Stations []StationStatus `json:"stations"`
+	}
+	if err := c.getFeed(ctx, "station_status", &feed); err != nil {
+		return nil, fmt.Errorf("%s: %w", errPrefix, err)
+	}
+	return feed.Stations, nil
+}
+
+// Station combines a station's information with its status.
+type Station struct {
+	StationInformation
+	// Status is nil if station_status.json has no entry for the station.
+	Status *StationStatus `json:"status,omitempty"`
+}
+
+// number is a float that GBFS before v2.2 encodes as a string such as
+// "2.00", and later versions as a number.
+type number float64
+
+func (n *number) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	f, err := strconv.ParseFloat(strings.Trim(string(b), `"`), 64)
+	if err != nil {
+		return fmt.Errorf("invalid number %s", b)
+	}
+	*n = number(f)
+	return nil
+}
+
+// Stations retrieves every station in the system along with its current
+// availability.
+func (c *Client) Stations() ([]Station, error) {
+	return c.StationsContext(context.Background())
+}
+
+// StationsContext is like Stations, but gives up when ctx is done.
+func (c *Client) StationsContext(ctx context.Context) ([]Station, error) {
+	info, err := c.StationInformationContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statuses, err := c.StationStatusContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*StationStatus, len(statuses))
+	for i := range statuses {
+		byID[statuses[i].StationID] = &statuses[i]
+	}
+	stations := make([]Station, len(info))
+	for i, station := range info {
+		stations[i] = Station{StationInformation: station, Status: byID[station.StationID]}
+	}
+	return stations, nil
+}
+
+// PricingPlan is what a trip costs under one plan, from
+// system_pricing_plans.json.
+type PricingPlan struct {
+	PlanID   string `json:"plan_id"`
+	Name     string `json:"name"`
+	Currency string `json:"currency"`
+	// Price is the fixed cost of a trip, usually charged on unlocking.
+	Price number `json:"price"`
+	// PerMinPricing are rates that apply from Start minutes into a trip,
+	// charging Rate every Interval minutes.
+	PerMinPricing []struct {
+		Start    number `json:"start"`
+		Rate     number `json:"rate"`
+		Interval number `json:"interval"`
+	} `json:"per_min_pricing"`
+}
+
+// PricingPlans retrieves the system's pricing plans.
+func (c *Client) PricingPlans() ([]PricingPlan, error) {
+	return c.PricingPlansContext(context.Background())
+}
+
+// PricingPlansContext is like PricingPlans, but gives up when ctx is done.
+//
+// system_pricing_plans is optional, so systems that don't publish it have
+// no plans rather than an ErrFeedNotFound.
+func (c *Client) PricingPlansContext(ctx context.Context) ([]PricingPlan, error) {
+	errPrefix := "gbfs.PricingPlans"
+
+	var feed struct {
+		Plans []PricingPlan `json:"plans"`
+	}
+	if err := c.getFeed(ctx, "system_pricing_plans", &feed); errors.Is(err, ErrFeedNotFound) {
+		return []PricingPlan{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("%s: %w", errPrefix, err)
+	}
+	return feed.Plans, nil
+}
+
+// feedURL returns the URL of the named feed, reading gbfs.json the first
+// time it's needed.
+func (c *Client) feedURL(ctx context.Context, name string) (string, error) {
+	c.feedsMu.Lock()
+	defer c.feedsMu.Unlock()
+	if c.feeds == nil {
+		var discovery map[string]struct {
+			Feeds []struct {
+				Name string `json:"name"`
+				URL  string `json:"url"`
+			} `json:"feeds"`
+		}
+		if err := c.getData(ctx, c.discoveryURL, &discovery); err != nil {
+			return "", err
+		}
+		// Feeds are listed per language. Use the configured one if it's
+		// there, and otherwise the first in alphabetical order.
+		language := c.language
+		if _, ok := discovery[language]; !ok {
+			var languages []string
+			for l := range discovery {
+				languages = append(languages, l)
+			}
+			sort.Strings(languages)
+			if len(languages) > 0 {
+				language = languages[0]
+			}
+		}
+		feeds := map[string]string{}
+		for _, feed := range discovery[language].Feeds {
+			feeds[feed.Name] = feed.URL
+		}
+		c.feeds = feeds
+	}
+	url, ok := c.feeds[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrFeedNotFound, name)
+	}
+	return url, nil
+}
+
+// getFeed decodes the data section of the named feed into v.
+func (c *Client) getFeed(ctx context.Context, name string, v interface{}) error {
+	url, err := c.feedURL(ctx, name)
+	if err != nil {
+		return err
+	}
+	return c.getData(ctx, url, v)
+}
+
+// getData fetches a GBFS file and decodes its data section into v.
+func (c *Client) getData(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+	if c.sem != nil {
+		select {
+		case c.sem <- struct{}{}:
+			defer func() { <-c.sem }()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		var bodyStr string
+		bodyBytes, err := io.ReadAll(res.Body)
+		if err != nil {
+			bodyStr = fmt.Sprintf("could not parse body (%s)", err.Error())
+		} else {
+			bodyStr = string(bodyBytes)
+		}
+		return fmt.Errorf("%w %d: %s", ErrUnexpectedStatus, res.StatusCode, bodyStr)
+	}
+
+	var payload struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&payload); err != nil {
+		return err
+	}
+	return json.Unmarshal(payload.Data, v)
+}
+
+// flag is a boolean that GBFS v1 encodes as 0 or 1 and v2 as true or
+// false.
+type flag bool
+
+func (f *flag) UnmarshalJSON(b []byte) error {
+	switch string(b) {
+	case "true", "1":
+		*f = true
+	case "false", "0", "null":
+		*f = false
+	default:
+		return fmt.Errorf("invalid boolean %s", b)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package gbfs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/themichaellai/bikealert/bikeshare"
+)
+
+// newTestServer serves a gbfs.json listing feeds, each of which responds
+// with the given data section.
+func newTestServer(t *testing.T, feeds map[string]string) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	var list string
+	for name, data := range feeds {
+		data := data
+		if list != "" {
+			list += ","
+		}
+		list += fmt.Sprintf(`{"name":%q,"url":%q}`, name, srv.URL+"/"+name+".json")
+		mux.HandleFunc("/"+name+".json", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"data":%s}`, data)
+		})
+	}
+	mux.HandleFunc("/gbfs.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"data":{"en":{"feeds":[%s]}}}`, list)
+	})
+	return srv
+}
+
+func TestBikesContext(t *testing.T) {
+	tests := []struct {
+		name      string
+		feeds     map[string]string
+		wantBikes int
+		wantErr   bool
+	}{
+		{
+			name:      "published",
+			feeds:     map[string]string{"free_bike_status": `{"bikes":[{"bike_id":"a"},{"bike_id":"b"}]}`},
+			wantBikes: 2,
+		},
+		{
+			name:  "dock-only system",
+			feeds: map[string]string{"station_information": `{"stations":[]}`},
+		},
+		{
+			name:    "undecodable",
+			feeds:   map[string]string{"free_bike_status": `{"bikes":"none"}`},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t, tt.feeds)
+			bikes, err := NewClient(srv.URL + "/gbfs.json").BikesContext(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BikesContext() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (bikes == nil || len(bikes) != tt.wantBikes) {
+				t.Errorf("BikesContext() = %v, want %d bikes", bikes, tt.wantBikes)
+			}
+		})
+	}
+}
+
+func TestBikesContextUnreachable(t *testing.T) {
+	srv := newTestServer(t, nil)
+	srv.Close()
+	if _, err := NewClient(srv.URL + "/gbfs.json").BikesContext(context.Background()); err == nil {
+		t.Error("BikesContext() error = nil for an unreachable system")
+	}
+}
+
+func TestWithMaxConcurrency(t *testing.T) {
+	tests := []struct {
+		n, wantMax int32
+	}{
+		{n: 1, wantMax: 1},
+		{n: 2, wantMax: 2},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.n), func(t *testing.T) {
+			var inFlight, maxInFlight int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&inFlight, 1)
+				defer atomic.AddInt32(&inFlight, -1)
+				for {
+					max := atomic.LoadInt32(&maxInFlight)
+					if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+						break
+					}
+				}
+				time.Sleep(20 * time.Millisecond)
+				fmt.Fprint(w, `{"data":{}}`)
+			}))
+			defer srv.Close()
+
+			c := NewClient(srv.URL, WithMaxConcurrency(int(tt.n)))
+			var wg sync.WaitGroup
+			for i := 0; i < 4; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					var v struct{}
+					c.getData(context.Background(), srv.URL, &v)
+				}()
+			}
+			wg.Wait()
+			if maxInFlight > tt.wantMax {
+				t.Errorf("%d requests in flight at once, want at most %d", maxInFlight, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestNearbyVehiclesPricing(t *testing.T) {
+	bikes := `{"bikes":[{"bike_id":"a","pricing_plan_id":"std"},{"bike_id":"b","pricing_plan_id":"gone"},{"bike_id":"c"}]}`
+	tests := []struct {
+		name  string
+		feeds map[string]string
+		// want is the pricing of bikes a, b and c, or nil for none.
+		want []*bikeshare.Pricing
+	}{
+		{
+			name: "v2.2 numbers",
+			feeds: map[string]string{
+				"free_bike_status":     bikes,
+				"system_pricing_plans": `{"plans":[{"plan_id":"std","currency":"USD","price":1,"per_min_pricing":[{"start":0,"rate":0.3,"interval":1}]}]}`,
+			},
+			want: []*bikeshare.Pricing{{Currency: "USD", UnlockFee: 1, PerMinute: 0.3}, nil, nil},
+		},
+		{
+			name: "strings",
+			feeds: map[string]string{
+				"free_bike_status":     bikes,
+				"system_pricing_plans": `{"plans":[{"plan_id":"std","currency":"EUR","price":"2.00","per_min_pricing":[{"start":"30","rate":"1","interval":"1"},{"start":"0","rate":"0.5","interval":"5"}]}]}`,
+			},
+			want: []*bikeshare.Pricing{{Currency: "EUR", UnlockFee: 2, PerMinute: 0.1}, nil, nil},
+		},
+		{
+			name:  "no pricing plans",
+			feeds: map[string]string{"free_bike_status": bikes},
+			want:  []*bikeshare.Pricing{nil, nil, nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t, tt.feeds)
+			vehicles, err := NewProvider(NewClient(srv.URL+"/gbfs.json")).NearbyVehicles(context.Background(), 0, 0)
+			if err != nil {
+				t.Fatalf("NearbyVehicles() error = %v", err)
+			}
+			if len(vehicles) != len(tt.want) {
+				t.Fatalf("NearbyVehicles() returned %d vehicles, want %d", len(vehicles), len(tt.want))
+			}
+			for i, want := range tt.want {
+				got := vehicles[i].Pricing
+				if (got == nil) != (want == nil) || (got != nil && *got != *want) {
+					t.Errorf("bike %s pricing = %+v, want %+v", vehicles[i].ID, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package gbfs
+
+import "net/http"
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient makes requests with the given client instead of one with
+// a 5 second timeout.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithMaxConcurrency limits how many requests the client has in flight at
+// once. n <= 0 means no limit.
+func WithMaxConcurrency(n int) Option {
+	return func(c *Client) {
+		if n <= 0 {
+			c.sem = nil
+			return
+		}
+		c.sem = make(chan struct{}, n)
+	}
+}
+
+// WithLanguage picks which language's feeds to use when gbfs.json lists
+// several. The default is "en".
+func WithLanguage(language string) Option {
+	return func(c *Client) {
+		c.language = language
+	}
+}
@@ -0,0 +1,99 @@
+package gbfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/themichaellai/bikealert/bikeshare"
+)
+
+func init() {
+	bikeshare.Register(ProviderName, newProviderFromSettings)
+}
+
+// Provider adapts a Client to bikeshare.Provider. GBFS feeds list the
+// whole system, so every bike and station is returned.
+type Provider struct {
+	*Client
+}
+
+// NewProvider returns a bikeshare.Provider backed by c.
+func NewProvider(c *Client) *Provider {
+	return &Provider{Client: c}
+}
+
+// NearbyVehicles returns every rentable free-floating bike in the system,
+// with pricing for bikes whose pricing plan is published.
+func (p *Provider) NearbyVehicles(ctx context.Context, latitude, longitude float64) ([]bikeshare.Vehicle, error) {
+	bikes, err := p.BikesContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	vehicles := Vehicles(bikes)
+	if err := p.addPricing(ctx, vehicles); err != nil {
+		return nil, err
+	}
+	return vehicles, nil
+}
+
+// addPricing sets the pricing of vehicles that name a pricing plan,
+// fetching the plans only if any do.
+func (p *Provider) addPricing(ctx context.Context, vehicles []bikeshare.Vehicle) error {
+	var planned bool
+	for _, v := range vehicles {
+		if bike, ok := BikeFromVehicle(v); ok && bike.PricingPlanID != "" {
+			planned = true
+			break
+		}
+	}
+	if !planned {
+		return nil
+	}
+	plans, err := p.PricingPlansContext(ctx)
+	if err != nil {
+		return err
+	}
+	byID := make(map[string]*bikeshare.Pricing, len(plans))
+	for _, plan := range plans {
+		byID[plan.PlanID] = plan.Pricing()
+	}
+	for i, v := range vehicles {
+		if bike, ok := BikeFromVehicle(v); ok {
+			vehicles[i].Pricing = byID[bike.PricingPlanID]
+		}
+	}
+	return nil
+}
+
+// NearbyStations returns every station in the system.
+func (p *Provider) NearbyStations(ctx context.Context, latitude, longitude float64) ([]bikeshare.Station, error) {
+	stations, err := p.StationsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return Stations(stations), nil
+}
+
+// newProviderFromSettings creates a Provider for bikeshare.Open. It needs
+// a "url" setting pointing at the system's gbfs.json, and takes an
+// optional "language" and "max_concurrency".
+func newProviderFromSettings(settings map[string]string) (bikeshare.Provider, error) {
+	discoveryURL, ok := settings["url"]
+	if !ok {
+		return nil, errors.New("missing \"url\" setting")
+	}
+	var opts []Option
+	if language, ok := settings["language"]; ok {
+		opts = append(opts, WithLanguage(language))
+	}
+	if s, ok := settings["max_concurrency"]; ok {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid \"max_concurrency\" setting: %w", err)
+		}
+		opts = append(opts, WithMaxConcurrency(n))
+	}
+	return NewProvider(NewClient(discoveryURL, opts...)), nil
+}
@@ -0,0 +1,111 @@
+package gbfs
+
+import (
+	"github.com/themichaellai/bikealert/bikeshare"
+)
+
+// ProviderName identifies GBFS systems in bikeshare.Vehicle.Provider and
+// bikeshare.Station.Provider.
+const ProviderName = "gbfs"
+
+const metersPerMile = 1609.344
+
+// Vehicle converts the bike to a bikeshare.Vehicle. The original Bike is
+// kept as the extension. Bikes that report a range are assumed to be
+// e-bikes, since telling bikes and scooters apart needs
+// vehicle_types.json.
+func (b Bike) Vehicle() bikeshare.Vehicle {
+	v := bikeshare.Vehicle{
+		Provider:     ProviderName,
+		ID:           b.BikeID,
+		Name:         b.BikeID,
+		Type:         bikeshare.VehicleBike,
+		Latitude:     b.Lat,
+		Longitude:    b.Lon,
+		BatteryLevel: -1,
+		RangeMiles:   b.CurrentRangeMeters / metersPerMile,
+		Extension:    &b,
+	}
+	if b.CurrentRangeMeters > 0 || b.CurrentFuelPercent != nil {
+		v.Type = bikeshare.VehicleEbike
+	}
+	if b.CurrentFuelPercent != nil {
+		v.BatteryLevel = int(*b.CurrentFuelPercent * 100)
+	}
+	return v
+}
+
+// Vehicles converts bikes to bikeshare.Vehicles. Reserved and disabled
+// bikes are left out, since they can't be rented.
+func Vehicles(bikes []Bike) []bikeshare.Vehicle {
+	vehicles := make([]bikeshare.Vehicle, 0, len(bikes))
+	for _, bike := range bikes {
+		if bike.IsReserved || bike.IsDisabled {
+			continue
+		}
+		vehicles = append(vehicles, bike.Vehicle())
+	}
+	return vehicles
+}
+
+// Pricing converts the plan to a bikeshare.Pricing. The per-minute price
+// is the rate at the start of a trip, since later rates only apply to
+// longer ones.
+func (p PricingPlan) Pricing() *bikeshare.Pricing {
+	pricing := &bikeshare.Pricing{Currency: p.Currency, UnlockFee: float64(p.Price)}
+	for _, segment := range p.PerMinPricing {
+		if segment.Start == 0 && segment.Interval > 0 {
+			pricing.PerMinute = float64(segment.Rate / segment.Interval)
+		}
+	}
+	return pricing
+}
+
+// BikeFromVehicle returns the GBFS bike a vehicle was converted from, if
+// it came from a GBFS system.
+func BikeFromVehicle(v bikeshare.Vehicle) (*Bike, bool) {
+	b, ok := v.Extension.(*Bike)
+	return b, ok
+}
+
+// Station converts the station to a bikeshare.Station. The original
+// Station is kept as the extension. Stations that aren't renting show no
+// available vehicles, and ones that aren't returning show no docks.
+func (s Station) Station() bikeshare.Station {
+	station := bikeshare.Station{
+		Provider:       ProviderName,
+		ID:             s.StationID,
+		Name:           s.Name,
+		Latitude:       s.Lat,
+		Longitude:      s.Lon,
+		Address:        s.Address,
+		AvailableDocks: -1,
+		Extension:      &s,
+	}
+	if s.Status != nil {
+		if s.Status.IsRenting {
+			station.AvailableVehicles = s.Status.NumBikesAvailable
+		}
+		station.AvailableDocks = 0
+		if s.Status.IsReturning {
+			station.AvailableDocks = s.Status.NumDocksAvailable
+		}
+	}
+	return station
+}
+
+// Stations converts stations to bikeshare.Stations.
+func Stations(stations []Station) []bikeshare.Station {
+	result := make([]bikeshare.Station, len(stations))
+	for i, station := range stations {
+		result[i] = station.Station()
+	}
+	return result
+}
+
+// StationFromBikeshare returns the GBFS station a bikeshare.Station was
+// converted from, if it came from a GBFS system.
+func StationFromBikeshare(s bikeshare.Station) (*Station, bool) {
+	station, ok := s.Extension.(*Station)
+	return station, ok
+}
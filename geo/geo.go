@@ -0,0 +1,44 @@
+// Package geo has helpers for working with coordinates.
+package geo
+
+import "math"
+
+// earthRadius is the radius of the Earth in miles.
+const earthRadius = 3958.756
+
+func hsin(theta float64) float64 {
+	return math.Pow(math.Sin(theta/2), 2)
+}
+
+// Distance returns distance between two coordinates in miles.
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	// convert to radians
+	la1 := lat1 * math.Pi / 180
+	lo1 := lon1 * math.Pi / 180
+	la2 := lat2 * math.Pi / 180
+	lo2 := lon2 * math.Pi / 180
+
+	h := hsin(la2-la1) + math.Cos(la1)*math.Cos(la2)*hsin(lo2-lo1)
+
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
+
+// Bearing returns the initial compass bearing in degrees, from 0 up to
+// 360, for travelling from the first coordinate to the second.
+func Bearing(lat1, lon1, lat2, lon2 float64) float64 {
+	la1 := lat1 * math.Pi / 180
+	la2 := lat2 * math.Pi / 180
+	dLon := (lon2 - lon1) * math.Pi / 180
+
+	y := math.Sin(dLon) * math.Cos(la2)
+	x := math.Cos(la1)*math.Sin(la2) - math.Sin(la1)*math.Cos(la2)*math.Cos(dLon)
+	bearing := math.Atan2(y, x) * 180 / math.Pi
+	return math.Mod(bearing+360, 360)
+}
+
+// CompassPoint returns the eight-point compass direction for a bearing in
+// degrees, e.g. "NE".
+func CompassPoint(bearing float64) string {
+	points := []string{"N", "NE", "E", "SE", "S", "SW", "W", "NW"}
+	return points[int(math.Round(math.Mod(bearing, 360)/45))%len(points)]
+}
@@ -0,0 +1,32 @@
+package geo
+
+import "math"
+
+const plusCodeAlphabet = "23456789CFGHJMPQRVWX"
+
+// plusCodeResolution is how many of the smallest cells fit in one degree
+// for a 10-digit code.
+const plusCodeResolution = 8000
+
+// PlusCode returns the 10-digit Open Location Code for a coordinate, e.g.
+// "849VQHFJ+X6". It identifies an area about 14 meters square.
+func PlusCode(lat, lng float64) string {
+	lat = math.Max(-90, math.Min(90, lat))
+	lng = math.Mod(math.Mod(lng+180, 360)+360, 360)
+
+	latVal := int64(math.Floor((lat + 90) * plusCodeResolution))
+	lngVal := int64(math.Floor(lng * plusCodeResolution))
+	// The north pole belongs to the cell below it.
+	if max := int64(180 * plusCodeResolution); latVal >= max {
+		latVal = max - 1
+	}
+
+	code := make([]byte, 10)
+	for i := 4; i >= 0; i-- {
+		code[2*i] = plusCodeAlphabet[latVal%20]
+		code[2*i+1] = plusCodeAlphabet[lngVal%20]
+		latVal /= 20
+		lngVal /= 20
+	}
+	return string(code[:8]) + "+" + string(code[8:])
+}
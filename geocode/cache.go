@@ -0,0 +1,88 @@
+package geocode
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Cache remembers another Geocoder's results in a JSON file, so the same
+// query is only ever sent once. Failed lookups aren't cached.
+type Cache struct {
+	geocoder Geocoder
+	path     string
+
+	mu     sync.Mutex
+	places map[string]Place
+}
+
+// NewCache wraps geocoder with a cache stored at path. The file is created
+// on the first successful lookup if it doesn't exist.
+func NewCache(geocoder Geocoder, path string) (*Cache, error) {
+	errPrefix := "geocode.NewCache"
+
+	c := &Cache{geocoder: geocoder, path: path, places: map[string]Place{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("%s: %w", errPrefix, err)
+	}
+	if err := json.Unmarshal(data, &c.places); err != nil {
+		return nil, fmt.Errorf("%s: parsing %s: %w", errPrefix, path, err)
+	}
+	return c, nil
+}
+
+// Geocode returns the cached place for query, looking it up and saving it
+// if it isn't cached yet. Queries differing only in case or surrounding
+// space share an entry.
+func (c *Cache) Geocode(ctx context.Context, query string) (Place, error) {
+	key := strings.ToLower(strings.TrimSpace(query))
+	c.mu.Lock()
+	place, ok := c.places[key]
+	c.mu.Unlock()
+	if ok {
+		return place, nil
+	}
+
+	place, err := c.geocoder.Geocode(ctx, query)
+	if err != nil {
+		return Place{}, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.places[key] = place
+	if err := c.save(); err != nil {
+		return Place{}, err
+	}
+	return place, nil
+}
+
+// save writes the cache to a temporary file and renames it into place, so
+// an interrupted write can't leave it truncated. c.mu must be held.
+func (c *Cache) save() error {
+	errPrefix := "geocode.Cache.save"
+
+	data, err := json.MarshalIndent(c.places, "", "  ")
+	if err != nil {
+		return fmt.Errorf("%s: %w", errPrefix, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("%s: %w", errPrefix, err)
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("%s: %w", errPrefix, err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("%s: %w", errPrefix, err)
+	}
+	return nil
+}
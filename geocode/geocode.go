@@ -0,0 +1,30 @@
+// Package geocode resolves place names and addresses to coordinates.
+package geocode
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrNotFound means the geocoder found no place matching the query.
+	ErrNotFound = errors.New("no matching place")
+	// ErrUnexpectedStatus means a geocoding service responded with a
+	// non-200 status.
+	ErrUnexpectedStatus = errors.New("unexpected status code")
+)
+
+// Place is a geocoding result.
+type Place struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	// Name is the geocoder's full name for the place, e.g. "123, Market
+	// Street, ... San Francisco, California, 94103, United States".
+	Name string `json:"name"`
+}
+
+// Geocoder resolves a free-form query, such as an address, to the best
+// matching place. It returns ErrNotFound if nothing matches.
+type Geocoder interface {
+	Geocode(ctx context.Context, query string) (Place, error)
+}
@@ -0,0 +1,87 @@
+package geocode
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultNominatimURL is OpenStreetMap's public Nominatim server. Its
+// usage policy allows at most one request per second, so repeated lookups
+// should go through a Cache.
+const DefaultNominatimURL = "https://nominatim.openstreetmap.org"
+
+const httpTimeout = 5 * time.Second
+
+// Nominatim geocodes using a Nominatim server.
+type Nominatim struct {
+	baseURL    string
+	userAgent  string
+	httpClient *http.Client
+}
+
+// NewNominatim creates a geocoder for the Nominatim server at baseURL.
+// Nominatim asks that userAgent identify the application.
+func NewNominatim(baseURL, userAgent string) *Nominatim {
+	return &Nominatim{
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		userAgent: userAgent,
+		httpClient: &http.Client{
+			Timeout: httpTimeout,
+		},
+	}
+}
+
+type nominatimPlace struct {
+	Lat         string `json:"lat"`
+	Lon         string `json:"lon"`
+	DisplayName string `json:"display_name"`
+}
+
+// Geocode returns Nominatim's best match for query.
+func (n *Nominatim) Geocode(ctx context.Context, query string) (Place, error) {
+	errPrefix := "geocode.Nominatim.Geocode"
+
+	q := url.Values{}
+	q.Set("q", query)
+	q.Set("format", "jsonv2")
+	q.Set("limit", "1")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, n.baseURL+"/search?"+q.Encode(), nil)
+	if err != nil {
+		return Place{}, fmt.Errorf("%s: %w", errPrefix, err)
+	}
+	req.Header.Set("User-Agent", n.userAgent)
+	res, err := n.httpClient.Do(req)
+	if err != nil {
+		return Place{}, fmt.Errorf("%s: %w", errPrefix, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 4096))
+		return Place{}, fmt.Errorf("%s: %w %d: %s", errPrefix, ErrUnexpectedStatus, res.StatusCode, body)
+	}
+	var places []nominatimPlace
+	if err := json.NewDecoder(res.Body).Decode(&places); err != nil {
+		return Place{}, fmt.Errorf("%s: %w", errPrefix, err)
+	}
+	if len(places) == 0 {
+		return Place{}, fmt.Errorf("%s: %w for %q", errPrefix, ErrNotFound, query)
+	}
+
+	lat, err := strconv.ParseFloat(places[0].Lat, 64)
+	if err != nil {
+		return Place{}, fmt.Errorf("%s: parsing latitude: %w", errPrefix, err)
+	}
+	lng, err := strconv.ParseFloat(places[0].Lon, 64)
+	if err != nil {
+		return Place{}, fmt.Errorf("%s: parsing longitude: %w", errPrefix, err)
+	}
+	return Place{Latitude: lat, Longitude: lng, Name: places[0].DisplayName}, nil
+}
@@ -1,5 +1,5 @@
 module github.com/themichaellai/bikealert
 
-go 1.12
+go 1.20
 
-require github.com/pkg/errors v0.8.1
+require gopkg.in/yaml.v3 v3.0.1
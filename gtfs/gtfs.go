@@ -0,0 +1,302 @@
+// Package gtfs reads GTFS static transit feeds to find upcoming
+// departures near a location.
+package gtfs
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/themichaellai/bikealert/geo"
+)
+
+// Stop is a place where vehicles pick up passengers.
+type Stop struct {
+	ID        string
+	Name      string
+	Latitude  float64
+	Longitude float64
+}
+
+// Departure is a scheduled departure from a stop.
+type Departure struct {
+	Stop      Stop
+	RouteName string
+	Headsign  string
+	Time      time.Time
+}
+
+// Feed is an opened GTFS zip file. Stops, routes, trips and calendars are
+// read up front; stop times are streamed on each query since they are by
+// far the largest part of a feed.
+type Feed struct {
+	path     string
+	location *time.Location
+
+	stops  []Stop
+	routes map[string]string
+	trips  map[string]trip
+
+	// services maps service IDs to which days of the week they run,
+	// indexed by time.Weekday, between start and end.
+	services map[string]service
+	// exceptions maps service IDs to dates (YYYYMMDD) added (true) or
+	// removed (false) from the regular calendar.
+	exceptions map[string]map[string]bool
+}
+
+type trip struct {
+	routeID   string
+	serviceID string
+	headsign  string
+}
+
+type service struct {
+	weekdays   [7]bool
+	start, end string
+}
+
+// Open reads a GTFS zip file. Times are interpreted in the first agency's
+// time zone, falling back to local time.
+func Open(path string) (*Feed, error) {
+	errPrefix := "gtfs.Open"
+
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", errPrefix, err)
+	}
+	defer r.Close()
+
+	f := &Feed{
+		path:       path,
+		location:   time.Local,
+		routes:     map[string]string{},
+		trips:      map[string]trip{},
+		services:   map[string]service{},
+		exceptions: map[string]map[string]bool{},
+	}
+
+	err = readTable(&r.Reader, "agency.txt", false, func(row map[string]string) error {
+		if loc, err := time.LoadLocation(row["agency_timezone"]); err == nil && f.location == time.Local {
+			f.location = loc
+		}
+		return nil
+	})
+	if err == nil {
+		err = readTable(&r.Reader, "stops.txt", true, func(row map[string]string) error {
+			// Stations (1), entrances (2) and the like have no departures.
+			if t := row["location_type"]; t != "" && t != "0" {
+				return nil
+			}
+			lat, err := strconv.ParseFloat(row["stop_lat"], 64)
+			if err != nil {
+				return err
+			}
+			lng, err := strconv.ParseFloat(row["stop_lon"], 64)
+			if err != nil {
+				return err
+			}
+			f.stops = append(f.stops, Stop{ID: row["stop_id"], Name: row["stop_name"], Latitude: lat, Longitude: lng})
+			return nil
+		})
+	}
+	if err == nil {
+		err = readTable(&r.Reader, "routes.txt", true, func(row map[string]string) error {
+			name := row["route_short_name"]
+			if name == "" {
+				name = row["route_long_name"]
+			}
+			f.routes[row["route_id"]] = name
+			return nil
+		})
+	}
+	if err == nil {
+		err = readTable(&r.Reader, "trips.txt", true, func(row map[string]string) error {
+			f.trips[row["trip_id"]] = trip{routeID: row["route_id"], serviceID: row["service_id"], headsign: row["trip_headsign"]}
+			return nil
+		})
+	}
+	if err == nil {
+		days := []string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"}
+		err = readTable(&r.Reader, "calendar.txt", false, func(row map[string]string) error {
+			s := service{start: row["start_date"], end: row["end_date"]}
+			for i, day := range days {
+				s.weekdays[i] = row[day] == "1"
+			}
+			f.services[row["service_id"]] = s
+			return nil
+		})
+	}
+	if err == nil {
+		err = readTable(&r.Reader, "calendar_dates.txt", false, func(row map[string]string) error {
+			id := row["service_id"]
+			if f.exceptions[id] == nil {
+				f.exceptions[id] = map[string]bool{}
+			}
+			f.exceptions[id][row["date"]] = row["exception_type"] == "1"
+			return nil
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", errPrefix, err)
+	}
+	return f, nil
+}
+
+// NearestStop returns the stop closest to a coordinate and its distance in
+// miles.
+func (f *Feed) NearestStop(lat, lng float64) (Stop, float64, error) {
+	var best Stop
+	bestDistance := math.Inf(1)
+	for _, stop := range f.stops {
+		if d := geo.Distance(lat, lng, stop.Latitude, stop.Longitude); d < bestDistance {
+			best, bestDistance = stop, d
+		}
+	}
+	if math.IsInf(bestDistance, 1) {
+		return Stop{}, 0, fmt.Errorf("gtfs.NearestStop: feed has no stops")
+	}
+	return best, bestDistance, nil
+}
+
+// NextDepartures returns up to limit departures from a stop at or after
+// the given time, soonest first.
+func (f *Feed) NextDepartures(stop Stop, after time.Time, limit int) ([]Departure, error) {
+	errPrefix := "gtfs.NextDepartures"
+
+	r, err := zip.OpenReader(f.path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", errPrefix, err)
+	}
+	defer r.Close()
+
+	after = after.In(f.location)
+	today := serviceDay(after)
+	// Trips running past midnight belong to yesterday's service, with
+	// times like "25:10:00".
+	yesterday := today.AddDate(0, 0, -1)
+
+	var departures []Departure
+	err = readTable(&r.Reader, "stop_times.txt", true, func(row map[string]string) error {
+		if row["stop_id"] != stop.ID {
+			return nil
+		}
+		t, ok := f.trips[row["trip_id"]]
+		if !ok {
+			return nil
+		}
+		offset, err := parseTime(row["departure_time"])
+		if err != nil {
+			return nil
+		}
+		for _, day := range []time.Time{yesterday, today} {
+			departure := day.Add(offset)
+			if departure.Before(after) || !f.runs(t.serviceID, day) {
+				continue
+			}
+			departures = append(departures, Departure{
+				Stop:      stop,
+				RouteName: f.routes[t.routeID],
+				Headsign:  t.headsign,
+				Time:      departure,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", errPrefix, err)
+	}
+
+	sort.Slice(departures, func(i, j int) bool {
+		return departures[i].Time.Before(departures[j].Time)
+	})
+	if len(departures) > limit {
+		departures = departures[:limit]
+	}
+	return departures, nil
+}
+
+// runs reports whether a service operates on the given day.
+func (f *Feed) runs(serviceID string, day time.Time) bool {
+	date := day.Format("20060102")
+	if added, ok := f.exceptions[serviceID][date]; ok {
+		return added
+	}
+	s, ok := f.services[serviceID]
+	return ok && s.weekdays[day.Weekday()] && s.start <= date && date <= s.end
+}
+
+// serviceDay returns midnight at the start of t's day. GTFS measures times
+// from noon minus 12 hours, which is midnight except on DST change days.
+func serviceDay(t time.Time) time.Time {
+	noon := time.Date(t.Year(), t.Month(), t.Day(), 12, 0, 0, 0, t.Location())
+	return noon.Add(-12 * time.Hour)
+}
+
+// parseTime parses an "HH:MM:SS" time, where HH may be 24 or more.
+func parseTime(s string) (time.Duration, error) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid time \"%s\"", s)
+	}
+	var total time.Duration
+	for i, unit := range []time.Duration{time.Hour, time.Minute, time.Second} {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil {
+			return 0, fmt.Errorf("invalid time \"%s\"", s)
+		}
+		total += time.Duration(n) * unit
+	}
+	return total, nil
+}
+
+// readTable calls fn for each row of a CSV file in the zip, keyed by
+// column name. Missing optional files are skipped.
+func readTable(r *zip.Reader, name string, required bool, fn func(row map[string]string) error) error {
+	file, err := r.Open(name)
+	if err != nil {
+		if required {
+			return err
+		}
+		return nil
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	columns := make([]string, len(header))
+	for i, column := range header {
+		columns[i] = strings.TrimSpace(strings.TrimPrefix(column, "\ufeff"))
+	}
+
+	row := make(map[string]string, len(columns))
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		for i, column := range columns {
+			if i < len(record) {
+				row[column] = record[i]
+			} else {
+				row[column] = ""
+			}
+		}
+		if err := fn(row); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+}
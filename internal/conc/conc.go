@@ -0,0 +1,67 @@
+// Package conc runs groups of tasks concurrently.
+package conc
+
+import (
+	"context"
+	"sync"
+)
+
+// Group runs tasks in their own goroutines, at most a fixed number at a
+// time. The first task to fail cancels the group's context, which the
+// other tasks should watch. It works like errgroup.Group with SetLimit.
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	sem    chan struct{}
+
+	wg      sync.WaitGroup
+	errOnce sync.Once
+	err     error
+}
+
+// WithContext returns a Group whose tasks get a context derived from ctx.
+// At most limit tasks run at once; zero or less means no limit.
+func WithContext(ctx context.Context, limit int) (*Group, context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	g := &Group{ctx: ctx, cancel: cancel}
+	if limit > 0 {
+		g.sem = make(chan struct{}, limit)
+	}
+	return g, ctx
+}
+
+// Go runs f in a new goroutine once there is room under the limit. If the
+// group's context is done first, f is skipped and the context's error is
+// recorded instead.
+func (g *Group) Go(f func(ctx context.Context) error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if g.sem != nil {
+			select {
+			case g.sem <- struct{}{}:
+				defer func() { <-g.sem }()
+			case <-g.ctx.Done():
+				g.fail(g.ctx.Err())
+				return
+			}
+		}
+		if err := f(g.ctx); err != nil {
+			g.fail(err)
+		}
+	}()
+}
+
+// Wait waits for every task to finish and returns the first error.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel()
+	return g.err
+}
+
+func (g *Group) fail(err error) {
+	g.errOnce.Do(func() {
+		g.err = err
+		g.cancel()
+	})
+}
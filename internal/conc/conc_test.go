@@ -0,0 +1,109 @@
+package conc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGroupLimit(t *testing.T) {
+	tests := []struct {
+		limit   int
+		wantMax int32
+	}{
+		{limit: 1, wantMax: 1},
+		{limit: 3, wantMax: 3},
+		// No limit lets all 8 run at once, but can't promise they do.
+		{limit: 0, wantMax: 8},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.limit), func(t *testing.T) {
+			var running, maxRunning int32
+			g, _ := WithContext(context.Background(), tt.limit)
+			for i := 0; i < 8; i++ {
+				g.Go(func(ctx context.Context) error {
+					n := atomic.AddInt32(&running, 1)
+					defer atomic.AddInt32(&running, -1)
+					for {
+						max := atomic.LoadInt32(&maxRunning)
+						if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+							break
+						}
+					}
+					time.Sleep(5 * time.Millisecond)
+					return nil
+				})
+			}
+			if err := g.Wait(); err != nil {
+				t.Fatalf("Wait() error = %v", err)
+			}
+			if maxRunning > tt.wantMax {
+				t.Errorf("%d tasks ran at once, want at most %d", maxRunning, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestGroupError(t *testing.T) {
+	errFirst := errors.New("first")
+	tests := []struct {
+		name  string
+		limit int
+	}{
+		// Room for both, since the waiting task may start first.
+		{name: "limited", limit: 2},
+		{name: "unlimited", limit: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, ctx := WithContext(context.Background(), tt.limit)
+			g.Go(func(ctx context.Context) error {
+				return errFirst
+			})
+			// This task waits for the failure to cancel it.
+			g.Go(func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			})
+			if err := g.Wait(); !errors.Is(err, errFirst) {
+				t.Errorf("Wait() error = %v, want %v", err, errFirst)
+			}
+			if ctx.Err() == nil {
+				t.Error("group context not cancelled after a failure")
+			}
+		})
+	}
+}
+
+func TestGroupSkipsAfterCancel(t *testing.T) {
+	parent, cancel := context.WithCancel(context.Background())
+	g, _ := WithContext(parent, 1)
+	// Hold the only slot, so the tasks below wait and see the
+	// cancellation instead.
+	started, release := make(chan struct{}), make(chan struct{})
+	g.Go(func(ctx context.Context) error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+	cancel()
+	var ran int32
+	for i := 0; i < 3; i++ {
+		g.Go(func(ctx context.Context) error {
+			atomic.AddInt32(&ran, 1)
+			return nil
+		})
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	if err := g.Wait(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait() error = %v, want context.Canceled", err)
+	}
+	if ran != 0 {
+		t.Errorf("%d tasks ran after the context was cancelled, want 0", ran)
+	}
+}
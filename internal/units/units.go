@@ -0,0 +1,72 @@
+// Package units parses human-friendly distances and percentages, such as
+// "0.5mi", "800m" and "60%".
+package units
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// metersPerMile is the number of meters in a mile.
+const metersPerMile = 1609.344
+
+// distanceUnits maps suffixes to the number of miles in one unit. Longer
+// suffixes come first so "mi" isn't mistaken for "m".
+var distanceUnits = []struct {
+	suffix string
+	miles  float64
+}{
+	{"mi", 1},
+	{"km", 1000 / metersPerMile},
+	{"ft", 1 / 5280.0},
+	{"m", 1 / metersPerMile},
+}
+
+// ParseDistance parses a distance such as "0.5mi", "800m", "1.2km" or
+// "300ft" and returns it in miles. A bare number is taken as miles.
+func ParseDistance(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	for _, unit := range distanceUnits {
+		if strings.HasSuffix(s, unit.suffix) {
+			n, err := parseNumber(strings.TrimSuffix(s, unit.suffix))
+			if err != nil {
+				return 0, fmt.Errorf("invalid distance \"%s\"", s)
+			}
+			return n * unit.miles, nil
+		}
+	}
+	n, err := parseNumber(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid distance \"%s\": expected a number with mi, km, m or ft", s)
+	}
+	return n, nil
+}
+
+// ParsePercent parses a percentage from 0 to 100, with or without a
+// trailing "%".
+func ParsePercent(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	n, err := parseNumber(strings.TrimSuffix(s, "%"))
+	if err != nil || n < 0 || n > 100 {
+		return 0, fmt.Errorf("invalid percentage \"%s\": expected 0 to 100", s)
+	}
+	return n, nil
+}
+
+// parseNumber parses a finite, non-negative number. ParseFloat also
+// accepts "NaN" and "Inf", which would pass or fail every limit.
+func parseNumber(s string) (float64, error) {
+	n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(n) || math.IsInf(n, 0) {
+		return 0, fmt.Errorf("non-finite value %f", n)
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("negative value %f", n)
+	}
+	return n, nil
+}
@@ -0,0 +1,74 @@
+package units
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseDistance(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    float64
+		wantErr bool
+	}{
+		{in: "0.5mi", want: 0.5},
+		{in: "2", want: 2},
+		{in: " 1609.344m ", want: 1},
+		{in: "1.609344km", want: 1},
+		{in: "5280ft", want: 1},
+		{in: "0", want: 0},
+		{in: "", wantErr: true},
+		{in: "far", wantErr: true},
+		{in: "1yd", wantErr: true},
+		{in: "-1mi", wantErr: true},
+		{in: "-1", wantErr: true},
+		{in: "NaN", wantErr: true},
+		{in: "nanmi", wantErr: true},
+		{in: "Inf", wantErr: true},
+		{in: "+Infkm", wantErr: true},
+		{in: "-Inf", wantErr: true},
+		{in: "1e400", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseDistance(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDistance(%q) error = %v, want error %t", tt.in, err, tt.wantErr)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("ParseDistance(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePercent(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    float64
+		wantErr bool
+	}{
+		{in: "60%", want: 60},
+		{in: "60", want: 60},
+		{in: " 0% ", want: 0},
+		{in: "100%", want: 100},
+		{in: "100.5%", wantErr: true},
+		{in: "-1%", wantErr: true},
+		{in: "half", wantErr: true},
+		{in: "NaN%", wantErr: true},
+		{in: "NaN", wantErr: true},
+		{in: "Inf%", wantErr: true},
+		{in: "-Inf", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParsePercent(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePercent(%q) error = %v, want error %t", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParsePercent(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
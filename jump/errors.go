@@ -0,0 +1,83 @@
+package jump
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Sentinel errors for the common failure classes. Errors returned by the
+// client can be checked against these with errors.Is.
+var (
+	// ErrRateLimited means upstream rate limited the request. The error
+	// is a *RateLimitedError, which says how long to wait.
+	ErrRateLimited = errors.New("rate limited")
+	// ErrUnexpectedStatus means upstream responded with a non-200 status.
+	ErrUnexpectedStatus = errors.New("unexpected status code")
+	// ErrUnknownSchema means the response didn't match any known payload
+	// version. The error is an *UnknownSchemaError.
+	ErrUnknownSchema = errors.New("unrecognized response schema")
+	// ErrResponseTooLarge means the response body was bigger than the
+	// client's limit. See WithMaxResponseBytes.
+	ErrResponseTooLarge = errors.New("response body too large")
+	// ErrBodyReadTimeout means the response headers arrived but the body
+	// took too long. See WithBodyReadTimeout.
+	ErrBodyReadTimeout = errors.New("timed out reading response body")
+)
+
+// RateLimitedError is returned when the upstream API rejects a request
+// for being rate limited.
+type RateLimitedError struct {
+	// RetryAfter is how long the server asked us to wait before trying
+	// again. It is zero if the server did not say.
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitedError) Error() string {
+	if e.RetryAfter == 0 {
+		return ErrRateLimited.Error()
+	}
+	return fmt.Sprintf("%s, retry after %s", ErrRateLimited, e.RetryAfter)
+}
+
+// Is makes errors.Is(err, ErrRateLimited) match.
+func (e *RateLimitedError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// rateLimitError returns a *RateLimitedError if res indicates rate
+// limiting, and nil otherwise.
+func rateLimitError(res *http.Response, now time.Time) error {
+	retryAfter, hasRetryAfter := parseRetryAfter(res.Header.Get("Retry-After"), now)
+	switch {
+	case res.StatusCode == http.StatusTooManyRequests:
+	case res.StatusCode == http.StatusServiceUnavailable && hasRetryAfter:
+	default:
+		return nil
+	}
+	return &RateLimitedError{RetryAfter: retryAfter}
+}
+
+// parseRetryAfter parses a Retry-After header, which is either a number of
+// seconds or an HTTP date.
+func parseRetryAfter(val string, now time.Time) (time.Duration, bool) {
+	if val == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(val); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	date, err := http.ParseTime(val)
+	if err != nil {
+		return 0, false
+	}
+	if wait := date.Sub(now); wait > 0 {
+		return wait, true
+	}
+	return 0, true
+}
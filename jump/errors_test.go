@@ -0,0 +1,73 @@
+package jump
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		val    string
+		want   time.Duration
+		wantOK bool
+	}{
+		{val: "", wantOK: false},
+		{val: "120", want: 2 * time.Minute, wantOK: true},
+		{val: "0", want: 0, wantOK: true},
+		{val: "-5", wantOK: false},
+		{val: "Fri, 16 Oct 2026 08:01:30 GMT", want: 90 * time.Second, wantOK: true},
+		// A date in the past means retry now.
+		{val: "Fri, 16 Oct 2026 07:00:00 GMT", want: 0, wantOK: true},
+		{val: "soon", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.val, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.val, testNow)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("parseRetryAfter(%q) = %s, %t, want %s, %t", tt.val, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRateLimitError(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		retryAfter string
+		// want is the RetryAfter of the error, or -1 for no error.
+		want time.Duration
+	}{
+		{name: "ok", status: http.StatusOK, want: -1},
+		{name: "too many requests", status: http.StatusTooManyRequests, want: 0},
+		{name: "too many requests with a wait", status: http.StatusTooManyRequests, retryAfter: "30", want: 30 * time.Second},
+		{name: "unavailable", status: http.StatusServiceUnavailable, want: -1},
+		{name: "unavailable with a wait", status: http.StatusServiceUnavailable, retryAfter: "30", want: 30 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+			if tt.retryAfter != "" {
+				res.Header.Set("Retry-After", tt.retryAfter)
+			}
+			err := rateLimitError(res, testNow)
+			if tt.want < 0 {
+				if err != nil {
+					t.Errorf("rateLimitError() = %v, want nil", err)
+				}
+				return
+			}
+			var rateLimited *RateLimitedError
+			if !errors.As(err, &rateLimited) || !errors.Is(err, ErrRateLimited) {
+				t.Fatalf("rateLimitError() = %v, want a *RateLimitedError", err)
+			}
+			if rateLimited.RetryAfter != tt.want {
+				t.Errorf("RetryAfter = %s, want %s", rateLimited.RetryAfter, tt.want)
+			}
+		})
+	}
+}
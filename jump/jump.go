@@ -1,33 +1,80 @@
+// Package jump is a client for the unofficial JUMP bikeshare API.
 package jump
 
 import (
+	"compress/gzip"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"math/rand"
 	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/pkg/errors"
+	"github.com/themichaellai/bikealert/clock"
 )
 
 // Client has methods for accessing JUMP data.
 type Client struct {
 	networkID string
 
-	httpClient *http.Client
+	headers     [][2]string
+	queryParams [][2]string
+	userAgents  []string
+	middleware  []Middleware
+
+	clock clock.Clock
+
+	// rand is used for anything random, if set. Otherwise the global
+	// source is used.
+	rand   *rand.Rand
+	randMu sync.Mutex
+	// sem limits concurrent requests when non-nil.
+	sem chan struct{}
+
+	timeout               time.Duration
+	responseHeaderTimeout time.Duration
+	bodyReadTimeout       time.Duration
+	maxResponseBytes      int64
+
+	httpClient  *http.Client
+	http1Client *http.Client
+	// http1Only is set to 1 once HTTP/2 has failed.
+	http1Only int32
+
+	stats Stats
 }
 
 const httpTimeout = 5 * time.Second
 
+// defaultMaxResponseBytes is comfortably more than a full network's bikes.
+const defaultMaxResponseBytes = 32 << 20
+
 // NewClient creates a new JUMP client. It will make requests with
 // respect to the given JUMP network ID.
-func NewClient(networkID string) *Client {
-	return &Client{
-		networkID: networkID,
-		httpClient: &http.Client{
-			Timeout: httpTimeout,
-		},
+func NewClient(networkID string, opts ...Option) *Client {
+	c := &Client{
+		networkID:        networkID,
+		clock:            clock.Real,
+		timeout:          httpTimeout,
+		maxResponseBytes: defaultMaxResponseBytes,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.httpClient = &http.Client{
+		Timeout:   c.timeout,
+		Transport: c.wrapTransport(c.newTransport(true)),
+	}
+	c.http1Client = &http.Client{
+		Timeout:   c.timeout,
+		Transport: c.wrapTransport(c.newTransport(false)),
 	}
+	return c
 }
 
 // Position contains coordinates for a bike or hub.
@@ -53,49 +100,23 @@ type Bike struct {
 	CurrentPosition      Position `json:"current_position"`
 }
 
-// bikesResponse is the response from /bikes.
-type bikesResponse struct {
-	CurrentPage  int64  `json:"current_position"`
-	PerPage      int64  `json:"per_page"`
-	TotalEntries int64  `json:"total_entries"`
-	Items        []Bike `json:"items"`
-}
-
 // Bikes retrieves all of the bikes for the network.
 func (c *Client) Bikes() ([]Bike, error) {
+	return c.BikesContext(context.Background())
+}
+
+// BikesContext is like Bikes, but gives up when ctx is done.
+func (c *Client) BikesContext(ctx context.Context) ([]Bike, error) {
 	errPrefix := "jump.Bikes"
 
 	url := fmt.Sprintf(
 		"https://app.jumpbikes.com/api/networks/%s/bikes?collapsed=false&per_page=999",
 		c.networkID)
-	req, err := c.newRequest(url)
-	if err != nil {
-		return nil, errors.Wrap(err, errPrefix)
+	var bikes []Bike
+	if err := c.getItems(ctx, url, &bikes); err != nil {
+		return nil, fmt.Errorf("%s: %w", errPrefix, err)
 	}
-
-	res, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, errors.Wrap(err, errPrefix)
-	} else if res.StatusCode != http.StatusOK {
-		defer res.Body.Close()
-		var body string
-		bodyBytes, err := ioutil.ReadAll(res.Body)
-		if err != nil {
-			body = fmt.Sprintf("could not parse body (%s)", err.Error())
-		} else {
-			body = string(bodyBytes)
-		}
-		return nil, errors.Wrap(
-			fmt.Errorf("got status code %d: %s", res.StatusCode, body),
-			errPrefix)
-	}
-
-	var parsedBody bikesResponse
-	defer res.Body.Close()
-	if err := json.NewDecoder(res.Body).Decode(&parsedBody); err != nil {
-		return nil, errors.Wrap(err, errPrefix)
-	}
-	return parsedBody.Items, nil
+	return bikes, nil
 }
 
 // Hub has information about a hub and its location.
@@ -130,59 +151,155 @@ type Hub struct {
 	Warehouse                 bool             `json:"warehouse"`
 }
 
-type hubResponse struct {
-	CurrentPage  int64 `json:"current_position"`
-	PerPage      int64 `json:"per_page"`
-	TotalEntries int64 `json:"total_entries"`
-	Items        []Hub `json:"items"`
-}
-
 // Hubs retrieves all of the hubs for the network.
 func (c *Client) Hubs() ([]Hub, error) {
+	return c.HubsContext(context.Background())
+}
+
+// HubsContext is like Hubs, but gives up when ctx is done.
+func (c *Client) HubsContext(ctx context.Context) ([]Hub, error) {
 	errPrefix := "jump.Hubs"
 
 	url := fmt.Sprintf(
 		"https://app.jumpbikes.com/api/networks/%s/hubs?collapsed=false&per_page=999",
 		c.networkID)
-	req, err := c.newRequest(url)
+	var hubs []Hub
+	if err := c.getItems(ctx, url, &hubs); err != nil {
+		return nil, fmt.Errorf("%s: %w", errPrefix, err)
+	}
+	return hubs, nil
+}
+
+// getItems fetches the given URL and decodes the list of items in the
+// response into v, whichever schema version the response uses.
+func (c *Client) getItems(ctx context.Context, rawURL string, v interface{}) error {
+	payload, err := c.get(ctx, rawURL)
 	if err != nil {
-		return nil, errors.Wrap(err, errPrefix)
+		return err
 	}
+	return decodeItems(payload, v)
+}
 
-	res, err := c.httpClient.Do(req)
+// get fetches the given URL and returns the response body.
+func (c *Client) get(ctx context.Context, rawURL string) ([]byte, error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	req, err := c.newRequest(ctx, rawURL)
 	if err != nil {
-		return nil, errors.Wrap(err, errPrefix)
-	} else if res.StatusCode != http.StatusOK {
-		defer res.Body.Close()
-		var body string
-		bodyBytes, err := ioutil.ReadAll(res.Body)
+		return nil, err
+	}
+
+	if c.sem != nil {
+		select {
+		case c.sem <- struct{}{}:
+			defer func() { <-c.sem }()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	atomic.AddInt64(&c.stats.Requests, 1)
+	res, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if c.bodyReadTimeout > 0 {
+		timer := time.AfterFunc(c.bodyReadTimeout, func() {
+			cancel(ErrBodyReadTimeout)
+		})
+		defer timer.Stop()
+	}
+
+	body, err := c.responseBody(res)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	if err := rateLimitError(res, c.clock.Now()); err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		var bodyStr string
+		bodyBytes, err := ioutil.ReadAll(body)
 		if err != nil {
-			body = fmt.Sprintf("could not parse body (%s)", err.Error())
+			bodyStr = fmt.Sprintf("could not parse body (%s)", err.Error())
 		} else {
-			body = string(bodyBytes)
+			bodyStr = string(bodyBytes)
 		}
-		return nil, errors.Wrap(
-			fmt.Errorf("got status code %d: %s", res.StatusCode, body),
-			errPrefix)
+		return nil, fmt.Errorf("%w %d: %s", ErrUnexpectedStatus, res.StatusCode, bodyStr)
 	}
 
-	var parsedBody hubResponse
-	defer res.Body.Close()
-	if err := json.NewDecoder(res.Body).Decode(&parsedBody); err != nil {
-		return nil, errors.Wrap(err, errPrefix)
+	payload, err := ioutil.ReadAll(body)
+	if err != nil && context.Cause(ctx) == ErrBodyReadTimeout {
+		return nil, ErrBodyReadTimeout
+	}
+	return payload, err
+}
+
+// responseBody returns the decompressed body of res, counting the bytes
+// read off the wire. Reading more than the client's maximum response size
+// fails with ErrResponseTooLarge.
+func (c *Client) responseBody(res *http.Response) (io.ReadCloser, error) {
+	var body io.ReadCloser = ioutil.NopCloser(&countingReader{r: res.Body, n: &c.stats.BytesRead})
+	if res.Header.Get("Content-Encoding") == "gzip" {
+		var err error
+		if body, err = gzip.NewReader(body); err != nil {
+			return nil, err
+		}
+	}
+	if c.maxResponseBytes <= 0 {
+		return body, nil
 	}
-	return parsedBody.Items, nil
+	return &limitedReader{ReadCloser: body, remaining: c.maxResponseBytes}, nil
+}
+
+// limitedReader fails with ErrResponseTooLarge once more than remaining
+// bytes have been read.
+type limitedReader struct {
+	io.ReadCloser
+	remaining int64
 }
 
-func (c *Client) newRequest(url string) (*http.Request, error) {
-	req, err := http.NewRequest("GET", url, nil)
+func (r *limitedReader) Read(p []byte) (int, error) {
+	if r.remaining < 0 {
+		return 0, ErrResponseTooLarge
+	}
+	if int64(len(p)) > r.remaining+1 {
+		p = p[:r.remaining+1]
+	}
+	n, err := r.ReadCloser.Read(p)
+	r.remaining -= int64(n)
+	if r.remaining < 0 {
+		return n, ErrResponseTooLarge
+	}
+	return n, err
+}
+
+func (c *Client) newRequest(ctx context.Context, rawURL string) (*http.Request, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if len(c.queryParams) > 0 {
+		q := u.Query()
+		for _, param := range c.queryParams {
+			q.Add(param[0], param[1])
+		}
+		u.RawQuery = q.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
 	if err != nil {
 		return nil, err
 	}
-	// Sorry.
-	req.Header.Add("Referer", fmt.Sprintf("https://map.jump.com/?network_id=%s&theme=jump", c.networkID))
-	req.Header.Add("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_14_6) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/76.0.3809.100 Safari/537.36")
-	req.Header.Add("Sec-Fetch-Mode", "cors")
-	req.Header.Add("Accept", "application/json, text/javascript, */*; q=0.01")
+	// Setting this ourselves disables the transport's transparent
+	// decompression, so responseBody handles it instead. That lets us
+	// count compressed bytes.
+	req.Header.Add("Accept-Encoding", "gzip")
+	for _, header := range c.headers {
+		req.Header.Set(header[0], header[1])
+	}
 	return req, nil
 }
@@ -0,0 +1,64 @@
+package jump
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+)
+
+const defaultUserAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_14_6) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/76.0.3809.100 Safari/537.36"
+
+// Middleware wraps the transport used for requests, e.g. to log, cache or
+// record them.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts a function to an http.RoundTripper.
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip calls f(req).
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// wrapTransport applies the client's middleware to rt. The first
+// middleware given is the outermost. The browser header middleware always
+// runs first, so other middleware sees the headers actually sent.
+func (c *Client) wrapTransport(rt http.RoundTripper) http.RoundTripper {
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		rt = c.middleware[i](rt)
+	}
+	return c.browserHeaders(rt)
+}
+
+// browserHeaders is the default middleware. It makes requests look like
+// they come from the JUMP web map. Headers already set on the request,
+// e.g. through WithHeader, are left alone.
+func (c *Client) browserHeaders(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		req = req.Clone(req.Context())
+		// Sorry.
+		setDefault(req.Header, "Referer", fmt.Sprintf("https://map.jump.com/?network_id=%s&theme=jump", c.networkID))
+		setDefault(req.Header, "User-Agent", c.userAgent())
+		setDefault(req.Header, "Sec-Fetch-Mode", "cors")
+		setDefault(req.Header, "Accept", "application/json, text/javascript, */*; q=0.01")
+		return next.RoundTrip(req)
+	})
+}
+
+func setDefault(header http.Header, name, value string) {
+	if header.Get(name) == "" {
+		header.Set(name, value)
+	}
+}
+
+func (c *Client) userAgent() string {
+	if len(c.userAgents) == 0 {
+		return defaultUserAgent
+	}
+	if c.rand == nil {
+		return c.userAgents[rand.Intn(len(c.userAgents))]
+	}
+	c.randMu.Lock()
+	defer c.randMu.Unlock()
+	return c.userAgents[c.rand.Intn(len(c.userAgents))]
+}
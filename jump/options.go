@@ -0,0 +1,109 @@
+package jump
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/themichaellai/bikealert/clock"
+)
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHeader adds a header that is sent with every request.
+func WithHeader(name, value string) Option {
+	return func(c *Client) {
+		c.headers = append(c.headers, [2]string{name, value})
+	}
+}
+
+// WithBearerToken sends the given token in an Authorization header.
+func WithBearerToken(token string) Option {
+	return WithHeader("Authorization", "Bearer "+token)
+}
+
+// WithQueryParam adds a query parameter to every request URL, for
+// providers that take an API key in the query string.
+func WithQueryParam(name, value string) Option {
+	return func(c *Client) {
+		c.queryParams = append(c.queryParams, [2]string{name, value})
+	}
+}
+
+// WithUserAgents replaces the default User-Agent header. When more than
+// one is given, each request picks one at random.
+func WithUserAgents(userAgents ...string) Option {
+	return func(c *Client) {
+		c.userAgents = userAgents
+	}
+}
+
+// WithMaxConcurrency limits how many requests the client has in flight at
+// once. Long-running callers should set this to 1 to go easy on the
+// upstream host. n <= 0 means no limit.
+func WithMaxConcurrency(n int) Option {
+	return func(c *Client) {
+		if n <= 0 {
+			c.sem = nil
+			return
+		}
+		c.sem = make(chan struct{}, n)
+	}
+}
+
+// WithMiddleware adds middleware around the client's transport, e.g. to
+// log, cache or record requests. The first middleware given is the
+// outermost.
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(c *Client) {
+		c.middleware = append(c.middleware, middleware...)
+	}
+}
+
+// WithTimeout sets the overall time limit for a request, including reading
+// the body. It defaults to 5 seconds.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithResponseHeaderTimeout limits how long to wait for response headers
+// after sending a request.
+func WithResponseHeaderTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.responseHeaderTimeout = timeout
+	}
+}
+
+// WithBodyReadTimeout limits how long reading the response body may take
+// once the headers have arrived.
+func WithBodyReadTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.bodyReadTimeout = timeout
+	}
+}
+
+// WithMaxResponseBytes limits the size of a decompressed response body.
+// It defaults to 32 MiB. Zero or less disables the limit.
+func WithMaxResponseBytes(n int64) Option {
+	return func(c *Client) {
+		c.maxResponseBytes = n
+	}
+}
+
+// WithRandSeed makes the client's random choices, such as which
+// User-Agent to send, repeat the same way for the same seed.
+func WithRandSeed(seed int64) Option {
+	return func(c *Client) {
+		c.rand = rand.New(rand.NewSource(seed))
+	}
+}
+
+// WithClock sets the clock used for Retry-After dates.
+// It defaults to clock.Real.
+func WithClock(clk clock.Clock) Option {
+	return func(c *Client) {
+		c.clock = clk
+	}
+}
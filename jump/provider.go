@@ -0,0 +1,96 @@
+package jump
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/themichaellai/bikealert/bikeshare"
+)
+
+func init() {
+	bikeshare.Register(ProviderName, newProviderFromSettings)
+}
+
+// Provider adapts a Client to bikeshare.Provider. JUMP can't be queried by
+// location, so every bike and hub in the network is returned.
+type Provider struct {
+	*Client
+}
+
+// NewProvider returns a bikeshare.Provider backed by c.
+func NewProvider(c *Client) *Provider {
+	return &Provider{Client: c}
+}
+
+// NearbyVehicles returns every bike in the network.
+func (p *Provider) NearbyVehicles(ctx context.Context, latitude, longitude float64) ([]bikeshare.Vehicle, error) {
+	bikes, err := p.BikesContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return Vehicles(bikes), nil
+}
+
+// NearbyStations returns every hub in the network.
+func (p *Provider) NearbyStations(ctx context.Context, latitude, longitude float64) ([]bikeshare.Station, error) {
+	hubs, err := p.HubsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return Stations(hubs), nil
+}
+
+// newProviderFromSettings creates a Provider for bikeshare.Open. It
+// understands these settings, all optional:
+//
+//	network          network ID, default NetworkSanFrancisco
+//	auth_token       sent as a bearer token
+//	auth_header      a header sent with every request, as "Name: value"
+//	auth_query       a query parameter added to every request, as "name=value"
+//	user_agents      User-Agents to rotate between, separated by "|"
+//	max_concurrency  most requests in flight at once, default no limit
+//	seed             seed for random behavior
+func newProviderFromSettings(settings map[string]string) (bikeshare.Provider, error) {
+	network := NetworkSanFrancisco
+	if id, ok := settings["network"]; ok {
+		network = id
+	}
+	var opts []Option
+	if token, ok := settings["auth_token"]; ok {
+		opts = append(opts, WithBearerToken(token))
+	}
+	if header, ok := settings["auth_header"]; ok {
+		name, value, found := strings.Cut(header, ":")
+		if !found || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid \"auth_header\" setting: want \"Name: value\"")
+		}
+		opts = append(opts, WithHeader(strings.TrimSpace(name), strings.TrimSpace(value)))
+	}
+	if param, ok := settings["auth_query"]; ok {
+		name, value, found := strings.Cut(param, "=")
+		if !found || name == "" {
+			return nil, fmt.Errorf("invalid \"auth_query\" setting: want \"name=value\"")
+		}
+		opts = append(opts, WithQueryParam(name, value))
+	}
+	if userAgents, ok := settings["user_agents"]; ok {
+		opts = append(opts, WithUserAgents(strings.Split(userAgents, "|")...))
+	}
+	if s, ok := settings["max_concurrency"]; ok {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid \"max_concurrency\" setting: %w", err)
+		}
+		opts = append(opts, WithMaxConcurrency(n))
+	}
+	if s, ok := settings["seed"]; ok {
+		seed, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithRandSeed(seed))
+	}
+	return NewProvider(NewClient(network, opts...)), nil
+}
@@ -0,0 +1,92 @@
+package jump
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNewProviderFromSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings map[string]string
+		// wantSem is the capacity of the client's semaphore, or -1 for
+		// none.
+		wantSem int
+		wantErr bool
+	}{
+		{name: "defaults", settings: map[string]string{}, wantSem: -1},
+		{name: "one at a time", settings: map[string]string{"max_concurrency": "1"}, wantSem: 1},
+		{name: "no limit", settings: map[string]string{"max_concurrency": "0"}, wantSem: -1},
+		{name: "invalid concurrency", settings: map[string]string{"max_concurrency": "one"}, wantErr: true},
+		{name: "invalid seed", settings: map[string]string{"seed": "x"}, wantErr: true},
+		{name: "invalid auth header", settings: map[string]string{"auth_header": "abc123"}, wantErr: true},
+		{name: "invalid auth query", settings: map[string]string{"auth_query": "abc123"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := newProviderFromSettings(tt.settings)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newProviderFromSettings() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			c := p.(*Provider).Client
+			if got := semCap(c.sem); got != tt.wantSem {
+				t.Errorf("semaphore capacity = %d, want %d", got, tt.wantSem)
+			}
+		})
+	}
+}
+
+func semCap(sem chan struct{}) int {
+	if sem == nil {
+		return -1
+	}
+	return cap(sem)
+}
+
+func TestNewProviderFromSettingsAuth(t *testing.T) {
+	tests := []struct {
+		name       string
+		settings   map[string]string
+		wantHeader [2]string
+		wantQuery  string
+	}{
+		{
+			name:       "bearer token",
+			settings:   map[string]string{"auth_token": "abc123"},
+			wantHeader: [2]string{"Authorization", "Bearer abc123"},
+		},
+		{
+			name:       "header",
+			settings:   map[string]string{"auth_header": "X-Api-Key: abc123"},
+			wantHeader: [2]string{"X-Api-Key", "abc123"},
+		},
+		{
+			name:      "query parameter",
+			settings:  map[string]string{"auth_query": "key=abc=123"},
+			wantQuery: "key=abc%3D123",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := newProviderFromSettings(tt.settings)
+			if err != nil {
+				t.Fatalf("newProviderFromSettings() error = %v", err)
+			}
+			req, err := p.(*Provider).newRequest(context.Background(), "https://example.com/bikes")
+			if err != nil {
+				t.Fatalf("newRequest() error = %v", err)
+			}
+			if tt.wantHeader[0] != "" {
+				if got := req.Header.Get(tt.wantHeader[0]); got != tt.wantHeader[1] {
+					t.Errorf("%s header = %q, want %q", tt.wantHeader[0], got, tt.wantHeader[1])
+				}
+			}
+			if got := req.URL.RawQuery; got != tt.wantQuery {
+				t.Errorf("query = %q, want %q", got, tt.wantQuery)
+			}
+		})
+	}
+}
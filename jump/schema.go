@@ -0,0 +1,104 @@
+package jump
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// schema is one version of the list payload returned by the JUMP API.
+type schema struct {
+	name string
+	// matches reports whether payload looks like this version.
+	matches func(payload []byte) bool
+	// items returns the raw list of items in payload.
+	items func(payload []byte) (json.RawMessage, error)
+}
+
+// schemas are probed in order, so newer versions should go first.
+var schemas = []schema{
+	{
+		// v1 is the paginated object, e.g.
+		// {"current_page": 1, "per_page": 999, "total_entries": 2, "items": [...]}
+		name:    "v1",
+		matches: hasKey("items"),
+		items:   field("items"),
+	},
+	{
+		// list is a bare array of items, without pagination.
+		name: "list",
+		matches: func(payload []byte) bool {
+			return bytes.HasPrefix(bytes.TrimSpace(payload), []byte("["))
+		},
+		items: func(payload []byte) (json.RawMessage, error) {
+			return payload, nil
+		},
+	},
+}
+
+// UnknownSchemaError is returned when a response doesn't match any known
+// version of the API payload, which usually means upstream changed it.
+type UnknownSchemaError struct {
+	// Keys are the top-level keys of the payload, if it was an object.
+	Keys []string
+}
+
+func (e *UnknownSchemaError) Error() string {
+	return fmt.Sprintf("%s (keys: %s)", ErrUnknownSchema, strings.Join(e.Keys, ", "))
+}
+
+// Is makes errors.Is(err, ErrUnknownSchema) match.
+func (e *UnknownSchemaError) Is(target error) bool {
+	return target == ErrUnknownSchema
+}
+
+// decodeItems decodes the list of items in payload into v, which should be
+// a pointer to a slice.
+func decodeItems(payload []byte, v interface{}) error {
+	for _, s := range schemas {
+		if !s.matches(payload) {
+			continue
+		}
+		items, err := s.items(payload)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(items, v); err != nil {
+			return fmt.Errorf("decoding %s payload: %w", s.name, err)
+		}
+		return nil
+	}
+
+	var keys []string
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &object); err == nil {
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+	}
+	return &UnknownSchemaError{Keys: keys}
+}
+
+func hasKey(key string) func([]byte) bool {
+	return func(payload []byte) bool {
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(payload, &object); err != nil {
+			return false
+		}
+		_, ok := object[key]
+		return ok
+	}
+}
+
+func field(key string) func([]byte) (json.RawMessage, error) {
+	return func(payload []byte) (json.RawMessage, error) {
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(payload, &object); err != nil {
+			return nil, err
+		}
+		return object[key], nil
+	}
+}
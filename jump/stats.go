@@ -0,0 +1,42 @@
+package jump
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// Stats has counters for the requests made by a Client.
+type Stats struct {
+	// Requests is the number of requests sent.
+	Requests int64
+	// BytesRead is the number of response body bytes received, before
+	// decompression.
+	BytesRead int64
+	// ReusedConns is the number of requests sent over an existing
+	// keep-alive connection.
+	ReusedConns int64
+	// HTTP2Responses is the number of responses received over HTTP/2.
+	HTTP2Responses int64
+}
+
+// Stats returns the client's request counters. Callers can compare two
+// snapshots to get the bandwidth used by a single poll.
+func (c *Client) Stats() Stats {
+	return Stats{
+		Requests:       atomic.LoadInt64(&c.stats.Requests),
+		BytesRead:      atomic.LoadInt64(&c.stats.BytesRead),
+		ReusedConns:    atomic.LoadInt64(&c.stats.ReusedConns),
+		HTTP2Responses: atomic.LoadInt64(&c.stats.HTTP2Responses),
+	}
+}
+
+type countingReader struct {
+	r io.Reader
+	n *int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	atomic.AddInt64(r.n, int64(n))
+	return n, err
+}
@@ -0,0 +1,61 @@
+package jump
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync/atomic"
+)
+
+// newTransport returns a keep-alive transport that negotiates HTTP/2 when
+// http2 is true and only speaks HTTP/1.1 otherwise.
+func (c *Client) newTransport(http2 bool) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.ForceAttemptHTTP2 = http2
+	t.ResponseHeaderTimeout = c.responseHeaderTimeout
+	if !http2 {
+		// A non-nil, empty map disables HTTP/2 upgrades.
+		t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	return t
+}
+
+// do sends req, preferring HTTP/2. If the upstream misbehaves over HTTP/2
+// the request is retried over HTTP/1.1, and the client sticks with
+// HTTP/1.1 from then on.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				atomic.AddInt64(&c.stats.ReusedConns, 1)
+			}
+		},
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+	if atomic.LoadInt32(&c.http1Only) == 1 {
+		return c.http1Client.Do(req)
+	}
+	res, err := c.httpClient.Do(req)
+	if err != nil && isHTTP2Error(err) {
+		atomic.StoreInt32(&c.http1Only, 1)
+		res, http1Err := c.http1Client.Do(req)
+		if http1Err != nil {
+			return nil, errors.Join(err, http1Err)
+		}
+		return res, nil
+	}
+	if err == nil && res.ProtoMajor == 2 {
+		atomic.AddInt64(&c.stats.HTTP2Responses, 1)
+	}
+	return res, err
+}
+
+// isHTTP2Error reports whether err came from the HTTP/2 layer. The
+// http2 error types are unexported, so this goes by the message.
+func isHTTP2Error(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "http2") || strings.Contains(msg, "stream error")
+}
@@ -0,0 +1,85 @@
+package jump
+
+import (
+	"strconv"
+
+	"github.com/themichaellai/bikealert/bikeshare"
+)
+
+// ProviderName identifies JUMP in bikeshare.Vehicle.Provider.
+const ProviderName = "jump"
+
+// Vehicle converts the bike to a bikeshare.Vehicle. The original Bike is
+// kept as the extension.
+func (b Bike) Vehicle() bikeshare.Vehicle {
+	vehicleType := bikeshare.VehicleEbike
+	if b.VehicleType == "scooter" {
+		vehicleType = bikeshare.VehicleScooter
+	}
+	v := bikeshare.Vehicle{
+		Provider:     ProviderName,
+		ID:           strconv.FormatInt(b.ID, 10),
+		Name:         b.Name,
+		Type:         vehicleType,
+		Address:      b.Address,
+		BatteryLevel: int(b.EbikeBatteryLevel),
+		// ebike_battery_distance has no documented unit, so RangeMiles is
+		// left unset. The bikes endpoint doesn't report prices either, so
+		// Pricing stays nil.
+		Extension: &b,
+	}
+	if coords := b.CurrentPosition.Coordinates; len(coords) == 2 {
+		v.Longitude, v.Latitude = coords[0], coords[1]
+	}
+	return v
+}
+
+// Vehicles converts bikes to bikeshare.Vehicles.
+func Vehicles(bikes []Bike) []bikeshare.Vehicle {
+	vehicles := make([]bikeshare.Vehicle, len(bikes))
+	for i, bike := range bikes {
+		vehicles[i] = bike.Vehicle()
+	}
+	return vehicles
+}
+
+// BikeFromVehicle returns the JUMP bike a vehicle was converted from, if
+// it came from JUMP.
+func BikeFromVehicle(v bikeshare.Vehicle) (*Bike, bool) {
+	b, ok := v.Extension.(*Bike)
+	return b, ok
+}
+
+// Station converts the hub to a bikeshare.Station. The original Hub is
+// kept as the extension.
+func (h Hub) Station() bikeshare.Station {
+	s := bikeshare.Station{
+		Provider:          ProviderName,
+		ID:                strconv.FormatFloat(h.ID, 'f', -1, 64),
+		Name:              h.Name,
+		Address:           h.Address,
+		AvailableVehicles: int(h.AvailableBikes + h.AvailableEbikes),
+		AvailableDocks:    int(h.FreeRacks),
+		Extension:         &h,
+	}
+	if coords := h.MiddlePoint.Coordinates; len(coords) == 2 {
+		s.Longitude, s.Latitude = coords[0], coords[1]
+	}
+	return s
+}
+
+// Stations converts hubs to bikeshare.Stations.
+func Stations(hubs []Hub) []bikeshare.Station {
+	stations := make([]bikeshare.Station, len(hubs))
+	for i, hub := range hubs {
+		stations[i] = hub.Station()
+	}
+	return stations
+}
+
+// HubFromStation returns the JUMP hub a station was converted from, if it
+// came from JUMP.
+func HubFromStation(s bikeshare.Station) (*Hub, bool) {
+	h, ok := s.Extension.(*Hub)
+	return h, ok
+}
@@ -0,0 +1,91 @@
+package notify
+
+import (
+	"fmt"
+
+	"github.com/themichaellai/bikealert/clock"
+)
+
+// Config describes one notifier, for loading from a config file. Type
+// picks the notifier and which other fields apply:
+//
+//	{"type": "email", "addr": "smtp.example.com:587", "username": "...", "password": "...", "from": "...", "to": ["..."]}
+//	{"type": "slack", "url": "https://hooks.slack.com/services/..."}
+//	{"type": "pushover", "token": "...", "user": "..."}
+//	{"type": "webhook", "url": "https://...", "headers": {"Authorization": "..."}}
+type Config struct {
+	Type string `json:"type" yaml:"type"`
+
+	URL     string            `json:"url,omitempty" yaml:"url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+
+	Addr     string   `json:"addr,omitempty" yaml:"addr,omitempty"`
+	Username string   `json:"username,omitempty" yaml:"username,omitempty"`
+	Password string   `json:"password,omitempty" yaml:"password,omitempty"`
+	From     string   `json:"from,omitempty" yaml:"from,omitempty"`
+	To       []string `json:"to,omitempty" yaml:"to,omitempty"`
+
+	Token string `json:"token,omitempty" yaml:"token,omitempty"`
+	User  string `json:"user,omitempty" yaml:"user,omitempty"`
+
+	// Clock is passed on to notifiers that date their messages.
+	Clock clock.Clock `json:"-" yaml:"-"`
+}
+
+// New creates the notifier a Config describes.
+func New(cfg Config) (Notifier, error) {
+	errPrefix := "notify.New"
+
+	var missing string
+	var n Notifier
+	switch cfg.Type {
+	case "email":
+		switch {
+		case cfg.Addr == "":
+			missing = "addr"
+		case cfg.From == "":
+			missing = "from"
+		case len(cfg.To) == 0:
+			missing = "to"
+		}
+		n = &Email{Addr: cfg.Addr, Username: cfg.Username, Password: cfg.Password, From: cfg.From, To: cfg.To,
+			Clock: cfg.Clock}
+	case "slack":
+		if cfg.URL == "" {
+			missing = "url"
+		}
+		n = &Slack{WebhookURL: cfg.URL}
+	case "pushover":
+		switch {
+		case cfg.Token == "":
+			missing = "token"
+		case cfg.User == "":
+			missing = "user"
+		}
+		n = &Pushover{Token: cfg.Token, User: cfg.User}
+	case "webhook":
+		if cfg.URL == "" {
+			missing = "url"
+		}
+		n = &Webhook{URL: cfg.URL, Headers: cfg.Headers, Clock: cfg.Clock}
+	default:
+		return nil, fmt.Errorf("%s: unknown notifier type \"%s\"", errPrefix, cfg.Type)
+	}
+	if missing != "" {
+		return nil, fmt.Errorf("%s: %s notifier needs \"%s\"", errPrefix, cfg.Type, missing)
+	}
+	return n, nil
+}
+
+// NewMulti creates a notifier that sends to every destination in cfgs.
+func NewMulti(cfgs []Config) (Notifier, error) {
+	notifiers := make([]Notifier, len(cfgs))
+	for i, cfg := range cfgs {
+		n, err := New(cfg)
+		if err != nil {
+			return nil, err
+		}
+		notifiers[i] = n
+	}
+	return Multi(notifiers...), nil
+}
@@ -0,0 +1,66 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/themichaellai/bikealert/clock"
+)
+
+// Email sends messages over SMTP. It uses STARTTLS when the server offers
+// it.
+type Email struct {
+	// Addr is the server's host:port, e.g. "smtp.example.com:587".
+	Addr string
+	// Username and Password are for PLAIN auth, if set.
+	Username string
+	Password string
+	From     string
+	To       []string
+	// Clock dates messages if set, instead of the system clock.
+	Clock clock.Clock
+}
+
+// Notify emails msg to every recipient. net/smtp doesn't take a context,
+// so ctx is only checked before sending.
+func (e *Email) Notify(ctx context.Context, msg Message) error {
+	errPrefix := "notify.Email"
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%s: %w", errPrefix, err)
+	}
+	var auth smtp.Auth
+	if e.Username != "" {
+		host, _, err := net.SplitHostPort(e.Addr)
+		if err != nil {
+			return fmt.Errorf("%s: %w", errPrefix, err)
+		}
+		auth = smtp.PlainAuth("", e.Username, e.Password, host)
+	}
+	if err := smtp.SendMail(e.Addr, auth, e.From, e.To, e.message(msg)); err != nil {
+		return fmt.Errorf("%s: %w", errPrefix, err)
+	}
+	return nil
+}
+
+// message formats msg as a plain text email.
+func (e *Email) message(msg Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", e.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(e.To, ", "))
+	// A line break in the title would end the header early.
+	fmt.Fprintf(&b, "Subject: %s\r\n", strings.NewReplacer("\r", " ", "\n", " ").Replace(msg.Title))
+	fmt.Fprintf(&b, "Date: %s\r\n", now(e.Clock).Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	if msg.URL != "" {
+		b.WriteString("\r\n\r\n" + msg.URL)
+	}
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
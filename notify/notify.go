@@ -0,0 +1,103 @@
+// Package notify sends alerts to people over email, Slack, Pushover or
+// any HTTP webhook.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/themichaellai/bikealert/clock"
+)
+
+// ErrUnexpectedStatus means a notification service responded with a
+// non-2xx status.
+var ErrUnexpectedStatus = errors.New("unexpected status code")
+
+const httpTimeout = 10 * time.Second
+
+// Message is a notification to send.
+type Message struct {
+	Title string
+	Body  string
+	// URL is an optional link to more detail, such as a map.
+	URL string
+}
+
+// Notifier delivers messages somewhere.
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
+
+// Multi sends each message to all of the given notifiers. It tries every
+// notifier even if some fail, and returns their errors joined.
+func Multi(notifiers ...Notifier) Notifier {
+	return multi(notifiers)
+}
+
+type multi []Notifier
+
+func (m multi) Notify(ctx context.Context, msg Message) error {
+	var errs []error
+	for _, n := range m {
+		if err := n.Notify(ctx, msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+var defaultHTTPClient = &http.Client{Timeout: httpTimeout}
+
+// now returns the time on c, or on the system clock if c is nil.
+func now(c clock.Clock) time.Time {
+	if c == nil {
+		c = clock.Real
+	}
+	return c.Now()
+}
+
+// postJSON sends v as JSON to url with the given extra headers.
+func postJSON(ctx context.Context, httpClient *http.Client, url string, headers map[string]string, v interface{}) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	return send(httpClient, req)
+}
+
+// send makes the request and checks for a 2xx status.
+func send(httpClient *http.Client, req *http.Request) error {
+	if httpClient == nil {
+		httpClient = defaultHTTPClient
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		var bodyStr string
+		bodyBytes, err := io.ReadAll(io.LimitReader(res.Body, 4096))
+		if err != nil {
+			bodyStr = fmt.Sprintf("could not parse body (%s)", err.Error())
+		} else {
+			bodyStr = string(bodyBytes)
+		}
+		return fmt.Errorf("%w %d: %s", ErrUnexpectedStatus, res.StatusCode, bodyStr)
+	}
+	return nil
+}
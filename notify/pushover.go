@@ -0,0 +1,47 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const pushoverURL = "https://api.pushover.net/1/messages.json"
+
+// Pushover sends messages to devices through Pushover.
+type Pushover struct {
+	// Token is the application's API token.
+	Token string
+	// User is the user or group key to deliver to.
+	User string
+	// HTTPClient is used for requests if set.
+	HTTPClient *http.Client
+}
+
+// Notify pushes msg to the user's devices.
+func (p *Pushover) Notify(ctx context.Context, msg Message) error {
+	errPrefix := "notify.Pushover"
+
+	form := url.Values{
+		"token":   {p.Token},
+		"user":    {p.User},
+		"message": {msg.Body},
+	}
+	if msg.Title != "" {
+		form.Set("title", msg.Title)
+	}
+	if msg.URL != "" {
+		form.Set("url", msg.URL)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", pushoverURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("%s: %w", errPrefix, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if err := send(p.HTTPClient, req); err != nil {
+		return fmt.Errorf("%s: %w", errPrefix, err)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Slack posts messages to a Slack incoming webhook.
+type Slack struct {
+	WebhookURL string
+	// HTTPClient is used for requests if set.
+	HTTPClient *http.Client
+}
+
+// Notify posts msg to the webhook's channel.
+func (s *Slack) Notify(ctx context.Context, msg Message) error {
+	errPrefix := "notify.Slack"
+
+	text := msg.Body
+	if msg.Title != "" {
+		text = fmt.Sprintf("*%s*\n%s", msg.Title, msg.Body)
+	}
+	if msg.URL != "" {
+		text += "\n" + msg.URL
+	}
+	err := postJSON(ctx, s.HTTPClient, s.WebhookURL, nil, map[string]string{"text": text})
+	if err != nil {
+		return fmt.Errorf("%s: %w", errPrefix, err)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/themichaellai/bikealert/clock"
+)
+
+// Webhook posts messages as JSON to any URL:
+//
+//	{"title": "...", "body": "...", "url": "...", "time": "2006-01-02T15:04:05Z"}
+type Webhook struct {
+	URL string
+	// Headers are added to every request, e.g. for authentication.
+	Headers map[string]string
+	// HTTPClient is used for requests if set.
+	HTTPClient *http.Client
+	// Clock dates messages if set, instead of the system clock.
+	Clock clock.Clock
+}
+
+type webhookPayload struct {
+	Title string    `json:"title"`
+	Body  string    `json:"body"`
+	URL   string    `json:"url,omitempty"`
+	Time  time.Time `json:"time"`
+}
+
+// Notify posts msg to the webhook URL.
+func (w *Webhook) Notify(ctx context.Context, msg Message) error {
+	errPrefix := "notify.Webhook"
+
+	payload := webhookPayload{Title: msg.Title, Body: msg.Body, URL: msg.URL, Time: now(w.Clock)}
+	if err := postJSON(ctx, w.HTTPClient, w.URL, w.Headers, payload); err != nil {
+		return fmt.Errorf("%s: %w", errPrefix, err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// migrations[v] upgrades a record from schema version v to v+1. Records
+// are decoded generically so a migration can rename or reshape fields
+// that the current types no longer have.
+var migrations = map[int]func(r map[string]interface{}) error{}
+
+// migrate rewrites every day file from schema version from to
+// SchemaVersion. Each file is replaced atomically and the meta file is
+// updated last, so an interrupted migration is simply run again.
+func (s *Store) migrate(from int) error {
+	for v := from; v < SchemaVersion; v++ {
+		if migrations[v] == nil {
+			return fmt.Errorf("no migration from schema version %d", v)
+		}
+	}
+	days, err := s.days()
+	if err != nil {
+		return err
+	}
+	for _, day := range days {
+		if err := s.migrateFile(s.dayPath(day)); err != nil {
+			return err
+		}
+	}
+	return s.writeMeta(SchemaVersion)
+}
+
+func (s *Store) migrateFile(path string) error {
+	var out bytes.Buffer
+	err := s.readLines(path, func(line []byte) error {
+		var r map[string]interface{}
+		if err := json.Unmarshal(line, &r); err != nil {
+			return err
+		}
+		// Records from before versioning count as version 1.
+		v := 1
+		if n, ok := r["v"].(float64); ok {
+			v = int(n)
+		}
+		for ; v < SchemaVersion; v++ {
+			if err := migrations[v](r); err != nil {
+				return fmt.Errorf("migrating from schema version %d: %w", v, err)
+			}
+		}
+		r["v"] = SchemaVersion
+		migrated, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		out.Write(migrated)
+		out.WriteByte('\n')
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, out.Bytes())
+}
+
+// writeFileAtomic writes data to a temporary file and renames it over
+// path, so readers never see a partial file.
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
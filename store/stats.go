@@ -0,0 +1,119 @@
+package store
+
+import (
+	"sort"
+	"time"
+)
+
+// MinPredictionSamples is how many samples an hour on one day of the week
+// needs before Predict trusts it over that hour on every day.
+const MinPredictionSamples = 4
+
+// Slot is an hour of the week.
+type Slot struct {
+	Weekday time.Weekday
+	Hour    int
+}
+
+// Availability counts how often a hub had vehicles.
+type Availability struct {
+	Samples int
+	// WithVehicles is how many samples had at least one vehicle.
+	WithVehicles  int
+	TotalVehicles int
+}
+
+// Chance returns the fraction of samples with at least one vehicle, or 0
+// if there are none.
+func (a Availability) Chance() float64 {
+	if a.Samples == 0 {
+		return 0
+	}
+	return float64(a.WithVehicles) / float64(a.Samples)
+}
+
+// Mean returns the average number of vehicles, or 0 if there are no
+// samples.
+func (a Availability) Mean() float64 {
+	if a.Samples == 0 {
+		return 0
+	}
+	return float64(a.TotalVehicles) / float64(a.Samples)
+}
+
+func (a *Availability) add(b Availability) {
+	a.Samples += b.Samples
+	a.WithVehicles += b.WithVehicles
+	a.TotalVehicles += b.TotalVehicles
+}
+
+// HubTrend is a hub's availability by hour of the week.
+type HubTrend struct {
+	ID string
+	// Name, Latitude and Longitude are from the latest snapshot.
+	Name      string
+	Latitude  float64
+	Longitude float64
+	Slots     map[Slot]Availability
+}
+
+// Trends groups the hubs in snaps by ID, counting each sample toward the
+// hour of the week it was taken in loc. It returns them sorted by name.
+func Trends(snaps []Snapshot, loc *time.Location) []*HubTrend {
+	trends := map[string]*HubTrend{}
+	for _, snap := range snaps {
+		t := snap.Time.In(loc)
+		slot := Slot{Weekday: t.Weekday(), Hour: t.Hour()}
+		for _, station := range snap.Stations {
+			trend, ok := trends[station.ID]
+			if !ok {
+				trend = &HubTrend{ID: station.ID, Slots: map[Slot]Availability{}}
+				trends[station.ID] = trend
+			}
+			trend.Name, trend.Latitude, trend.Longitude = station.Name, station.Latitude, station.Longitude
+
+			a := trend.Slots[slot]
+			a.Samples++
+			a.TotalVehicles += station.AvailableVehicles
+			if station.AvailableVehicles > 0 {
+				a.WithVehicles++
+			}
+			trend.Slots[slot] = a
+		}
+	}
+
+	result := make([]*HubTrend, 0, len(trends))
+	for _, trend := range trends {
+		result = append(result, trend)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+// Hour combines an hour's samples across every day of the week.
+func (h *HubTrend) Hour(hour int) Availability {
+	var a Availability
+	for slot, slotAvailability := range h.Slots {
+		if slot.Hour == hour {
+			a.add(slotAvailability)
+		}
+	}
+	return a
+}
+
+// Predict returns the samples to estimate availability at t from: that
+// hour on t's day of the week if it has at least MinPredictionSamples,
+// and that hour on every day otherwise. t should be in the location
+// passed to Trends. Its Chance is the chance of finding a vehicle.
+func (h *HubTrend) Predict(t time.Time) Availability {
+	a := h.Slots[Slot{Weekday: t.Weekday(), Hour: t.Hour()}]
+	if a.Samples >= MinPredictionSamples {
+		return a
+	}
+	return h.Hour(t.Hour())
+}
@@ -0,0 +1,356 @@
+// Package store keeps a history of bikeshare availability on disk, so it
+// can be analyzed later.
+//
+// Snapshots are appended as JSON lines to one file per UTC day, which keeps
+// writes cheap on slow storage and lets old days be pruned by deleting
+// whole files.
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/themichaellai/bikealert/bikeshare"
+	"github.com/themichaellai/bikealert/geo"
+)
+
+// SchemaVersion is the version of the records this package writes.
+const SchemaVersion = 1
+
+// ErrNewerSchema means the store was written by a newer version of this
+// package and can't be read safely.
+var ErrNewerSchema = errors.New("store schema is newer than supported")
+
+const (
+	metaFile   = "meta.json"
+	dayLayout  = "2006-01-02"
+	dayFileExt = ".jsonl"
+)
+
+// Snapshot is the availability around an origin at one point in time.
+type Snapshot struct {
+	Time     time.Time `json:"time"`
+	Provider string    `json:"provider"`
+	// Latitude and Longitude are the origin the snapshot was taken
+	// around.
+	Latitude  float64   `json:"latitude"`
+	Longitude float64   `json:"longitude"`
+	Vehicles  []Vehicle `json:"vehicles"`
+	Stations  []Station `json:"stations"`
+}
+
+// Vehicle is a vehicle as recorded in a snapshot.
+type Vehicle struct {
+	ID        string  `json:"id"`
+	Name      string  `json:"name"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	// BatteryLevel is a percentage, or -1 if unknown.
+	BatteryLevel int `json:"battery_level"`
+}
+
+// Station is a hub as recorded in a snapshot.
+type Station struct {
+	ID                string  `json:"id"`
+	Name              string  `json:"name"`
+	Latitude          float64 `json:"latitude"`
+	Longitude         float64 `json:"longitude"`
+	AvailableVehicles int     `json:"available_vehicles"`
+	// AvailableDocks is -1 if the provider doesn't report it.
+	AvailableDocks int `json:"available_docks"`
+}
+
+// NewSnapshot records vehicles and stations seen around an origin at t.
+// Only those within radius miles of the origin are kept, since providers
+// often return a whole network; a radius of 0 keeps everything.
+func NewSnapshot(t time.Time, provider string, latitude, longitude, radius float64,
+	vehicles []bikeshare.Vehicle, stations []bikeshare.Station) Snapshot {
+	within := func(lat, lng float64) bool {
+		return radius == 0 || geo.Distance(latitude, longitude, lat, lng) <= radius
+	}
+	snap := Snapshot{
+		Time:      t,
+		Provider:  provider,
+		Latitude:  latitude,
+		Longitude: longitude,
+		Vehicles:  []Vehicle{},
+		Stations:  []Station{},
+	}
+	for _, v := range vehicles {
+		if !within(v.Latitude, v.Longitude) {
+			continue
+		}
+		snap.Vehicles = append(snap.Vehicles, Vehicle{
+			ID:           v.ID,
+			Name:         v.Name,
+			Latitude:     v.Latitude,
+			Longitude:    v.Longitude,
+			BatteryLevel: v.BatteryLevel,
+		})
+	}
+	for _, s := range stations {
+		if !within(s.Latitude, s.Longitude) {
+			continue
+		}
+		snap.Stations = append(snap.Stations, Station{
+			ID:                s.ID,
+			Name:              s.Name,
+			Latitude:          s.Latitude,
+			Longitude:         s.Longitude,
+			AvailableVehicles: s.AvailableVehicles,
+			AvailableDocks:    s.AvailableDocks,
+		})
+	}
+	return snap
+}
+
+// record is one line of a day file.
+type record struct {
+	Version int `json:"v"`
+	Snapshot
+}
+
+type meta struct {
+	SchemaVersion int `json:"schema_version"`
+}
+
+// Store is a directory of snapshots. It is safe for concurrent use within
+// one process.
+type Store struct {
+	// Warn, if set, is called with each line skipped while reading
+	// because it isn't valid JSON, as a write interrupted by a crash or
+	// power loss leaves.
+	Warn func(err error)
+
+	dir string
+	mu  sync.Mutex
+}
+
+// Open opens the store in dir, creating it if needed and migrating
+// records written by older versions of this package.
+func Open(dir string) (*Store, error) {
+	errPrefix := "store.Open"
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("%s: %w", errPrefix, err)
+	}
+	s := &Store{dir: dir}
+	version, err := s.schemaVersion()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", errPrefix, err)
+	}
+	if version > SchemaVersion {
+		return nil, fmt.Errorf("%s: %w: %d > %d", errPrefix, ErrNewerSchema, version, SchemaVersion)
+	}
+	if version < SchemaVersion {
+		if err := s.migrate(version); err != nil {
+			return nil, fmt.Errorf("%s: %w", errPrefix, err)
+		}
+	}
+	return s, nil
+}
+
+// schemaVersion returns the version in the meta file. A store with no
+// meta file yet is new, and gets one for the current version.
+func (s *Store) schemaVersion() (int, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, metaFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return SchemaVersion, s.writeMeta(SchemaVersion)
+	} else if err != nil {
+		return 0, err
+	}
+	var m meta
+	if err := json.Unmarshal(data, &m); err != nil {
+		return 0, fmt.Errorf("parsing %s: %w", metaFile, err)
+	}
+	return m.SchemaVersion, nil
+}
+
+func (s *Store) writeMeta(version int) error {
+	data, err := json.Marshal(meta{SchemaVersion: version})
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(s.dir, metaFile), data)
+}
+
+// Add appends a snapshot to the file for its day.
+func (s *Store) Add(snap Snapshot) error {
+	errPrefix := "store.Add"
+
+	line, err := json.Marshal(record{Version: SchemaVersion, Snapshot: snap})
+	if err != nil {
+		return fmt.Errorf("%s: %w", errPrefix, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.dayPath(snap.Time), os.O_RDWR|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("%s: %w", errPrefix, err)
+	}
+	// An interrupted write can leave the file without a final newline.
+	// End that line first, so this record isn't glued onto it.
+	terminated, err := endsInNewline(f)
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("%s: %w", errPrefix, err)
+	}
+	if !terminated {
+		line = append([]byte{'\n'}, line...)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("%s: %w", errPrefix, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("%s: %w", errPrefix, err)
+	}
+	return nil
+}
+
+// endsInNewline reports whether f is empty or its last byte is a newline.
+func endsInNewline(f *os.File) (bool, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+	if info.Size() == 0 {
+		return true, nil
+	}
+	last := make([]byte, 1)
+	if _, err := f.ReadAt(last, info.Size()-1); err != nil {
+		return false, err
+	}
+	return last[0] == '\n', nil
+}
+
+// Snapshots returns the snapshots taken in [from, to), oldest first.
+// Lines cut short, e.g. by power loss during a write, are skipped and
+// passed to Warn.
+func (s *Store) Snapshots(from, to time.Time) ([]Snapshot, error) {
+	errPrefix := "store.Snapshots"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	days, err := s.days()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", errPrefix, err)
+	}
+	var snaps []Snapshot
+	for _, day := range days {
+		if !day.Add(24*time.Hour).After(from) || !day.Before(to) {
+			continue
+		}
+		err := s.readDay(day, func(r record) {
+			if !r.Time.Before(from) && r.Time.Before(to) {
+				snaps = append(snaps, r.Snapshot)
+			}
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", errPrefix, err)
+		}
+	}
+	sort.SliceStable(snaps, func(i, j int) bool { return snaps[i].Time.Before(snaps[j].Time) })
+	return snaps, nil
+}
+
+// Prune deletes the days that ended before cutoff, returning how many
+// were deleted.
+func (s *Store) Prune(cutoff time.Time) (int, error) {
+	errPrefix := "store.Prune"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	days, err := s.days()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", errPrefix, err)
+	}
+	pruned := 0
+	for _, day := range days {
+		if day.Add(24 * time.Hour).After(cutoff) {
+			continue
+		}
+		if err := os.Remove(s.dayPath(day)); err != nil {
+			return pruned, fmt.Errorf("%s: %w", errPrefix, err)
+		}
+		pruned++
+	}
+	return pruned, nil
+}
+
+// days returns the start of each day with a file, oldest first.
+func (s *Store) days() ([]time.Time, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var days []time.Time
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), dayFileExt)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		day, err := time.Parse(dayLayout, name)
+		if err != nil {
+			continue
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+func (s *Store) dayPath(t time.Time) string {
+	return filepath.Join(s.dir, t.UTC().Format(dayLayout)+dayFileExt)
+}
+
+// readDay calls fn with each record in a day's file.
+func (s *Store) readDay(day time.Time, fn func(record)) error {
+	return s.readLines(s.dayPath(day), func(line []byte) error {
+		var r record
+		if err := json.Unmarshal(line, &r); err != nil {
+			return err
+		}
+		if r.Version > SchemaVersion {
+			return fmt.Errorf("%w: record version %d", ErrNewerSchema, r.Version)
+		}
+		fn(r)
+		return nil
+	})
+}
+
+// readLines calls fn with each line of a file. Lines that aren't valid
+// JSON, which an interrupted write may have left, are skipped and passed
+// to Warn.
+func (s *Store) readLines(path string, fn func(line []byte) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 64<<20)
+	n := 0
+	for scanner.Scan() {
+		n++
+		if !json.Valid(scanner.Bytes()) {
+			if s.Warn != nil {
+				s.Warn(fmt.Errorf("%s:%d: skipping incomplete record", path, n))
+			}
+			continue
+		}
+		if err := fn(scanner.Bytes()); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+	}
+	return scanner.Err()
+}
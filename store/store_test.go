@@ -0,0 +1,213 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/themichaellai/bikealert/bikeshare"
+)
+
+var day = time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+
+func snapshotAt(t time.Time) Snapshot {
+	return Snapshot{Time: t, Provider: "test", Vehicles: []Vehicle{}, Stations: []Station{}}
+}
+
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+	st, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	return st
+}
+
+func TestSnapshotsSkipsTornWrites(t *testing.T) {
+	tests := []struct {
+		name string
+		// torn is written to the day file after the first snapshot, as if
+		// a write had been cut short.
+		torn      string
+		wantWarns int
+	}{
+		{name: "no torn write", torn: ""},
+		{name: "torn write", torn: `{"v":1,"time":"2026-10-16T01:`, wantWarns: 1},
+		{name: "torn write ending in a newline", torn: `{"v":1,"time":` + "\n", wantWarns: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := openTestStore(t)
+			var warns []error
+			st.Warn = func(err error) { warns = append(warns, err) }
+
+			if err := st.Add(snapshotAt(day.Add(time.Hour))); err != nil {
+				t.Fatalf("Add() error = %v", err)
+			}
+			f, err := os.OpenFile(st.dayPath(day), os.O_WRONLY|os.O_APPEND, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f.WriteString(tt.torn)
+			f.Close()
+			for _, h := range []int{2, 3} {
+				if err := st.Add(snapshotAt(day.Add(time.Duration(h) * time.Hour))); err != nil {
+					t.Fatalf("Add() error = %v", err)
+				}
+			}
+
+			snaps, err := st.Snapshots(day, day.Add(24*time.Hour))
+			if err != nil {
+				t.Fatalf("Snapshots() error = %v", err)
+			}
+			if len(snaps) != 3 {
+				t.Errorf("Snapshots() returned %d snapshots, want 3", len(snaps))
+			}
+			if len(warns) != tt.wantWarns {
+				t.Errorf("Warn called %d times (%v), want %d", len(warns), warns, tt.wantWarns)
+			}
+		})
+	}
+}
+
+func TestSnapshotsRange(t *testing.T) {
+	st := openTestStore(t)
+	for _, offset := range []time.Duration{-time.Hour, time.Hour, 25 * time.Hour, 49 * time.Hour} {
+		if err := st.Add(snapshotAt(day.Add(offset))); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	tests := []struct {
+		from, to time.Time
+		want     int
+	}{
+		{day, day.Add(24 * time.Hour), 1},
+		{day, day.Add(48 * time.Hour), 2},
+		{day.Add(-24 * time.Hour), day.Add(72 * time.Hour), 4},
+		{day.Add(2 * time.Hour), day.Add(25 * time.Hour), 0},
+	}
+	for _, tt := range tests {
+		snaps, err := st.Snapshots(tt.from, tt.to)
+		if err != nil {
+			t.Fatalf("Snapshots(%s, %s) error = %v", tt.from, tt.to, err)
+		}
+		if len(snaps) != tt.want {
+			t.Errorf("Snapshots(%s, %s) returned %d snapshots, want %d", tt.from, tt.to, len(snaps), tt.want)
+		}
+		for i := 1; i < len(snaps); i++ {
+			if snaps[i].Time.Before(snaps[i-1].Time) {
+				t.Errorf("Snapshots(%s, %s) isn't oldest first", tt.from, tt.to)
+			}
+		}
+	}
+}
+
+func TestSnapshotsNewerRecord(t *testing.T) {
+	st := openTestStore(t)
+	line := `{"v":2,"time":"2026-10-16T01:00:00Z"}` + "\n"
+	if err := os.WriteFile(st.dayPath(day), []byte(line), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.Snapshots(day, day.Add(24*time.Hour)); !errors.Is(err, ErrNewerSchema) {
+		t.Errorf("Snapshots() error = %v, want ErrNewerSchema", err)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	st := openTestStore(t)
+	for _, offset := range []time.Duration{-25 * time.Hour, -time.Hour, time.Hour} {
+		if err := st.Add(snapshotAt(day.Add(offset))); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	pruned, err := st.Prune(day)
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if pruned != 2 {
+		t.Errorf("Prune() = %d, want 2", pruned)
+	}
+	snaps, _ := st.Snapshots(time.Time{}, day.Add(48*time.Hour))
+	if len(snaps) != 1 {
+		t.Errorf("%d snapshots left after Prune(), want 1", len(snaps))
+	}
+}
+
+func TestOpenNewerSchema(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, metaFile), []byte(`{"schema_version":2}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(dir); !errors.Is(err, ErrNewerSchema) {
+		t.Errorf("Open() error = %v, want ErrNewerSchema", err)
+	}
+}
+
+func TestOpenMigrates(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, metaFile), []byte(`{"schema_version":0}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	old := `{"v":0,"time":"2026-10-16T01:00:00Z","provider":"test","bikes":[]}` + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "2026-10-16.jsonl"), []byte(old), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("missing migration", func(t *testing.T) {
+		if _, err := Open(dir); err == nil {
+			t.Error("Open() error = nil with no migration from version 0")
+		}
+	})
+
+	t.Run("migration", func(t *testing.T) {
+		migrations[0] = func(r map[string]interface{}) error {
+			r["vehicles"] = r["bikes"]
+			delete(r, "bikes")
+			return nil
+		}
+		defer delete(migrations, 0)
+
+		st, err := Open(dir)
+		if err != nil {
+			t.Fatalf("Open() error = %v", err)
+		}
+		snaps, err := st.Snapshots(day, day.Add(24*time.Hour))
+		if err != nil {
+			t.Fatalf("Snapshots() error = %v", err)
+		}
+		if len(snaps) != 1 || snaps[0].Vehicles == nil {
+			t.Errorf("Snapshots() = %+v, want one migrated snapshot", snaps)
+		}
+		if version, err := st.schemaVersion(); err != nil || version != SchemaVersion {
+			t.Errorf("schemaVersion() = %d, %v, want %d", version, err, SchemaVersion)
+		}
+	})
+}
+
+func TestNewSnapshotRadius(t *testing.T) {
+	vehicles := []bikeshare.Vehicle{
+		{ID: "near", Latitude: 37.776, Longitude: -122.418},
+		{ID: "far", Latitude: 37.876, Longitude: -122.418},
+	}
+	stations := []bikeshare.Station{
+		{ID: "near", Latitude: 37.777, Longitude: -122.418},
+		{ID: "far", Latitude: 37.676, Longitude: -122.418},
+	}
+	tests := []struct {
+		radius                 float64
+		wantVehicles, wantHubs int
+	}{
+		{radius: 0, wantVehicles: 2, wantHubs: 2},
+		{radius: 1, wantVehicles: 1, wantHubs: 1},
+		{radius: 10, wantVehicles: 2, wantHubs: 2},
+	}
+	for _, tt := range tests {
+		snap := NewSnapshot(day, "test", 37.776, -122.418, tt.radius, vehicles, stations)
+		if len(snap.Vehicles) != tt.wantVehicles || len(snap.Stations) != tt.wantHubs {
+			t.Errorf("NewSnapshot(radius %v) kept %d vehicles and %d hubs, want %d and %d",
+				tt.radius, len(snap.Vehicles), len(snap.Stations), tt.wantVehicles, tt.wantHubs)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Package what3words converts coordinates to what3words addresses.
+package what3words
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const httpTimeout = 5 * time.Second
+
+// Client converts coordinates using the what3words API.
+type Client struct {
+	apiKey string
+
+	httpClient *http.Client
+}
+
+// NewClient creates a new what3words client using the given API key.
+func NewClient(apiKey string) *Client {
+	return &Client{
+		apiKey: apiKey,
+		httpClient: &http.Client{
+			Timeout: httpTimeout,
+		},
+	}
+}
+
+type convertResponse struct {
+	Words string `json:"words"`
+	Error *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Words returns the what3words address for a coordinate, e.g.
+// "filled.count.soap".
+func (c *Client) Words(lat, lng float64) (string, error) {
+	errPrefix := "what3words.Words"
+
+	q := url.Values{}
+	q.Set("coordinates", fmt.Sprintf("%f,%f", lat, lng))
+	q.Set("key", c.apiKey)
+	res, err := c.httpClient.Get("https://api.what3words.com/v3/convert-to-3wa?" + q.Encode())
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", errPrefix, err)
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", errPrefix, err)
+	}
+	var parsedBody convertResponse
+	if err := json.Unmarshal(body, &parsedBody); err != nil {
+		return "", fmt.Errorf("%s: got status code %d: %s", errPrefix, res.StatusCode, body)
+	}
+	if parsedBody.Error != nil {
+		return "", fmt.Errorf("%s: %s: %s", errPrefix, parsedBody.Error.Code, parsedBody.Error.Message)
+	}
+	return parsedBody.Words, nil
+}